@@ -0,0 +1,236 @@
+//Package faketme serves paged TME taxonomy terms over HTTP for local development and end-to-end tests
+package faketme
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTaxonomy = "PN"
+	defaultSource   = "authorityfiles"
+)
+
+//Term is a TME taxonomy term as served by the fake
+type Term struct {
+	ID      string
+	Name    string
+	Aliases []string
+}
+
+//Config sets the credentials the fake expects, an empty value is not checked
+type Config struct {
+	Username string
+	Password string
+	Token    string
+	Taxonomy string
+	Source   string
+}
+
+//Server is an in-process TME, its URL replaces the TME base url
+type Server struct {
+	*httptest.Server
+	mu        sync.Mutex
+	config    Config
+	terms     []Term
+	latency   time.Duration
+	failures  []int
+	malformed int
+	requests  int
+}
+
+type taxonomy struct {
+	XMLName xml.Name `xml:"taxonomy"`
+	Terms   []term   `xml:"term"`
+}
+
+type term struct {
+	XMLName    xml.Name    `xml:"term"`
+	Name       string      `xml:"name"`
+	ID         string      `xml:"id"`
+	Variations *variations `xml:"variations,omitempty"`
+}
+
+type variations struct {
+	Variation []variation `xml:"variation"`
+}
+
+type variation struct {
+	Name string `xml:"name"`
+}
+
+//NewServer starts a fake TME serving the terms, Close it when done
+func NewServer(config Config, terms []Term) *Server {
+	if config.Taxonomy == "" {
+		config.Taxonomy = defaultTaxonomy
+	}
+	if config.Source == "" {
+		config.Source = defaultSource
+	}
+	s := &Server{config: config, terms: terms}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+//GeneratePeople makes up n people with an alias each
+func GeneratePeople(n int) []Term {
+	terms := make([]Term, n)
+	for i := range terms {
+		terms[i] = Term{
+			ID:      fmt.Sprintf("fake-person-%d", i+1),
+			Name:    fmt.Sprintf("Fake Person %d", i+1),
+			Aliases: []string{fmt.Sprintf("F. Person %d", i+1)},
+		}
+	}
+	return terms
+}
+
+//SetTerms replaces the terms served from the next request
+func (s *Server) SetTerms(terms []Term) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.terms = terms
+}
+
+//SetLatency delays every response
+func (s *Server) SetLatency(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = latency
+}
+
+//FailNext answers the next n requests with the given status
+func (s *Server) FailNext(n int, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		s.failures = append(s.failures, status)
+	}
+}
+
+//MalformNext answers the next n requests with truncated XML
+func (s *Server) MalformNext(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.malformed += n
+}
+
+//Requests counts the requests received, including rejected ones
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	latency := s.latency
+	failure := 0
+	if len(s.failures) > 0 {
+		failure, s.failures = s.failures[0], s.failures[1:]
+	}
+	malformed := failure == 0 && s.malformed > 0
+	if malformed {
+		s.malformed--
+	}
+	terms := s.terms
+	s.mu.Unlock()
+
+	time.Sleep(latency)
+	if !s.authorised(req) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if failure != 0 {
+		http.Error(w, http.StatusText(failure), failure)
+		return
+	}
+
+	prefix := "/rs/" + s.config.Source + "/" + s.config.Taxonomy + "/terms"
+	if req.Method != "GET" || !strings.HasPrefix(req.URL.Path, prefix) {
+		http.NotFound(w, req)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml;charset=utf-8")
+	if malformed {
+		fmt.Fprint(w, "<taxonomy><term><name>Truncated")
+		return
+	}
+
+	if id := strings.TrimPrefix(strings.TrimPrefix(req.URL.Path, prefix), "/"); id != "" {
+		for _, t := range terms {
+			if t.ID == id {
+				writeXML(w, newTerm(t))
+				return
+			}
+		}
+		http.NotFound(w, req)
+		return
+	}
+
+	start, err := queryInt(req, "startRecord", 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	max, err := queryInt(req, "maximumRecords", len(terms))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page := taxonomy{Terms: []term{}}
+	for i := start; i < start+max && i < len(terms); i++ {
+		page.Terms = append(page.Terms, newTerm(terms[i]))
+	}
+	writeXML(w, page)
+}
+
+func (s *Server) authorised(req *http.Request) bool {
+	if s.config.Username != "" || s.config.Password != "" {
+		username, password, ok := req.BasicAuth()
+		if !ok || username != s.config.Username || password != s.config.Password {
+			return false
+		}
+	}
+	return s.config.Token == "" || req.Header.Get("X-Coco-Auth") == s.config.Token
+}
+
+func newTerm(t Term) term {
+	xmlTerm := term{Name: t.Name, ID: t.ID}
+	if len(t.Aliases) > 0 {
+		xmlTerm.Variations = &variations{}
+		for _, alias := range t.Aliases {
+			xmlTerm.Variations.Variation = append(xmlTerm.Variations.Variation, variation{Name: alias})
+		}
+	}
+	return xmlTerm
+}
+
+func queryInt(req *http.Request, name string, defaultValue int) (int, error) {
+	value := req.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("Invalid %v [%v]", name, value)
+	}
+	return i, nil
+}
+
+func writeXML(w http.ResponseWriter, v interface{}) {
+	b, err := xml.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte(xml.Header))
+	w.Write(b)
+}
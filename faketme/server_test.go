@@ -0,0 +1,102 @@
+package faketme
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/tme-reader/tmereader"
+	"github.com/Financial-Times/v1-people-transformer/people"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServePages(t *testing.T) {
+	s := NewServer(Config{}, GeneratePeople(3))
+	defer s.Close()
+
+	tests := []struct {
+		name string
+		path string
+		code int
+		body string
+	}{
+		{"First page", "/rs/authorityfiles/PN/terms?maximumRecords=2&startRecord=0", http.StatusOK,
+			`<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<taxonomy><term><name>Fake Person 1</name><id>fake-person-1</id><variations><variation><name>F. Person 1</name></variation></variations></term><term><name>Fake Person 2</name><id>fake-person-2</id><variations><variation><name>F. Person 2</name></variation></variations></term></taxonomy>`},
+		{"Last page", "/rs/authorityfiles/PN/terms?maximumRecords=2&startRecord=2", http.StatusOK,
+			`<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<taxonomy><term><name>Fake Person 3</name><id>fake-person-3</id><variations><variation><name>F. Person 3</name></variation></variations></term></taxonomy>`},
+		{"Past the end", "/rs/authorityfiles/PN/terms?maximumRecords=2&startRecord=4", http.StatusOK,
+			`<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<taxonomy></taxonomy>`},
+		{"By id", "/rs/authorityfiles/PN/terms/fake-person-2", http.StatusOK,
+			`<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<term><name>Fake Person 2</name><id>fake-person-2</id><variations><variation><name>F. Person 2</name></variation></variations></term>`},
+		{"Unknown id", "/rs/authorityfiles/PN/terms/nobody", http.StatusNotFound, "404 page not found\n"},
+		{"Other taxonomy", "/rs/authorityfiles/ON/terms", http.StatusNotFound, "404 page not found\n"},
+		{"Invalid paging", "/rs/authorityfiles/PN/terms?startRecord=first", http.StatusBadRequest, "Invalid startRecord [first]\n"},
+	}
+	for _, test := range tests {
+		resp, err := http.Get(s.URL + test.path)
+		assert.NoError(t, err, test.name)
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.NoError(t, err, test.name)
+		assert.Equal(t, test.code, resp.StatusCode, test.name)
+		assert.Equal(t, test.body, string(body), test.name)
+	}
+	assert.Equal(t, len(tests), s.Requests())
+}
+
+func TestCredentials(t *testing.T) {
+	s := NewServer(Config{Username: "user", Password: "pass", Token: "token"}, GeneratePeople(1))
+	defer s.Close()
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		token    string
+		code     int
+	}{
+		{"Authorised", "user", "pass", "token", http.StatusOK},
+		{"Wrong password", "user", "wrong", "token", http.StatusUnauthorized},
+		{"Wrong token", "user", "pass", "wrong", http.StatusUnauthorized},
+		{"No credentials", "", "", "", http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		req, err := http.NewRequest("GET", s.URL+"/rs/authorityfiles/PN/terms", nil)
+		assert.NoError(t, err)
+		if test.username != "" {
+			req.SetBasicAuth(test.username, test.password)
+		}
+		req.Header.Set("X-Coco-Auth", test.token)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err, test.name)
+		resp.Body.Close()
+		assert.Equal(t, test.code, resp.StatusCode, test.name)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	s := NewServer(Config{}, GeneratePeople(2))
+	defer s.Close()
+	repo := tmereader.NewTmeRepository(http.DefaultClient, s.URL, "", "", "", 10, 1, "PN", &tmereader.AuthorityFiles{}, new(people.PersonTransformer))
+
+	s.FailNext(1, http.StatusServiceUnavailable)
+	_, err := repo.GetTmeTermsFromIndex(0)
+	assert.Error(t, err)
+
+	s.MalformNext(1)
+	_, err = repo.GetTmeTermsFromIndex(0)
+	assert.Error(t, err)
+
+	s.SetLatency(50 * time.Millisecond)
+	start := time.Now()
+	terms, err := repo.GetTmeTermsFromIndex(0)
+	assert.NoError(t, err)
+	assert.Len(t, terms, 2)
+	assert.True(t, time.Since(start) >= 50*time.Millisecond)
+
+	s.SetTerms(GeneratePeople(5))
+	terms, err = repo.GetTmeTermsFromIndex(0)
+	assert.NoError(t, err)
+	assert.Len(t, terms, 5)
+}
@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/Financial-Times/base-ft-rw-app-go/baseftrwapp"
@@ -14,12 +16,23 @@ import (
 	"github.com/Financial-Times/service-status-go/gtg"
 	status "github.com/Financial-Times/service-status-go/httphandlers"
 	"github.com/Financial-Times/tme-reader/tmereader"
+	"github.com/Financial-Times/v1-people-transformer/faketme"
 	"github.com/Financial-Times/v1-people-transformer/people"
 	log "github.com/Sirupsen/logrus"
 	"github.com/gorilla/mux"
 	"github.com/jawher/mow.cli"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rcrowley/go-metrics"
 	"github.com/sethgrid/pester"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 func main() {
@@ -97,34 +110,305 @@ func main() {
 		EnvVar: "LOG_METRICS",
 	})
 
+	tmeFile := app.String(cli.StringOpt{
+		Name:   "tme-file",
+		Value:  "",
+		Desc:   "TME taxonomy XML export, or directory of exported pages, to read people from instead of the TME API, e.g. in dev or air-gapped environments",
+		EnvVar: "TME_FILE",
+	})
+	fakeTME := app.Bool(cli.BoolOpt{
+		Name:   "fake-tme",
+		Value:  false,
+		Desc:   "Serve made up people from an in-process fake TME instead of calling the TME base url, for local development",
+		EnvVar: "FAKE_TME",
+	})
+	fakeTMEPeople := app.Int(cli.IntOpt{
+		Name:   "fake-tme-people",
+		Value:  100,
+		Desc:   "Number of people served by the fake TME",
+		EnvVar: "FAKE_TME_PEOPLE",
+	})
+	publishTo := app.String(cli.StringOpt{
+		Name:   "publish-to",
+		Value:  "",
+		Desc:   "Publish a message per person added, changed or removed by a load to kafka, stdout or file:{path}. Leave empty to not publish",
+		EnvVar: "PUBLISH_TO",
+	})
+	kafkaProxyAddress := app.String(cli.StringOpt{
+		Name:   "kafka-proxy-address",
+		Value:  "http://localhost:8082",
+		Desc:   "Kafka REST proxy the people are published through",
+		EnvVar: "KAFKA_PROXY_ADDRESS",
+	})
+	kafkaTopic := app.String(cli.StringOpt{
+		Name:   "kafka-topic",
+		Value:  "Concept",
+		Desc:   "Kafka topic the people are published to",
+		EnvVar: "KAFKA_TOPIC",
+	})
+	webhookURLs := app.Strings(cli.StringsOpt{
+		Name:   "webhook-urls",
+		Value:  []string{},
+		Desc:   "Comma separated urls notified with a POST when a load finishes",
+		EnvVar: "WEBHOOK_URLS",
+	})
+	webhookSecret := app.String(cli.StringOpt{
+		Name:   "webhook-secret",
+		Value:  "",
+		Desc:   "Secret the webhook notifications are signed with, in the X-Signature header",
+		EnvVar: "WEBHOOK_SECRET",
+	})
+	conceptRWURL := app.String(cli.StringOpt{
+		Name:   "concept-rw-url",
+		Value:  "",
+		Desc:   "Concept RW endpoint the people are PUT to after each load, e.g. http://localhost:8080/people. Leave empty to not write them",
+		EnvVar: "CONCEPT_RW_URL",
+	})
+	conceptRWConcurrency := app.Int(cli.IntOpt{
+		Name:   "concept-rw-concurrency",
+		Value:  8,
+		Desc:   "Maximum number of requests in flight to the concept RW",
+		EnvVar: "CONCEPT_RW_CONCURRENCY",
+	})
+	conceptRWOnlyChanged := app.Bool(cli.BoolOpt{
+		Name:   "concept-rw-only-changed",
+		Value:  false,
+		Desc:   "Only write the people added, changed or removed by a load to the concept RW",
+		EnvVar: "CONCEPT_RW_ONLY_CHANGED",
+	})
+	maxShrinkPercent := app.Int(cli.IntOpt{
+		Name:   "max-shrink-percent",
+		Value:  0,
+		Desc:   "Reject a load, keeping the people served, when it has more than this percentage fewer people. 0 accepts every load",
+		EnvVar: "MAX_SHRINK_PERCENT",
+	})
+	prometheusMetrics := app.Bool(cli.BoolOpt{
+		Name:   "prometheus-metrics",
+		Value:  false,
+		Desc:   "Expose the HTTP and load metrics to Prometheus on /metrics",
+		EnvVar: "PROMETHEUS_METRICS",
+	})
+	logLevel := app.String(cli.StringOpt{
+		Name:   "log-level",
+		Value:  "info",
+		Desc:   "Level of the logs: debug, info, warn or error",
+		EnvVar: "LOG_LEVEL",
+	})
+	logFormat := app.String(cli.StringOpt{
+		Name:   "log-format",
+		Value:  "json",
+		Desc:   "Format of the logs: json, or text when running locally",
+		EnvVar: "LOG_FORMAT",
+	})
+	tracing := app.String(cli.StringOpt{
+		Name:   "tracing",
+		Value:  "",
+		Desc:   "Export tracing spans to stdout or otlp. Empty does not trace",
+		EnvVar: "TRACING",
+	})
+	otlpEndpoint := app.String(cli.StringOpt{
+		Name:   "otlp-endpoint",
+		Value:  "http://localhost:4318",
+		Desc:   "URL of the OTLP collector the spans are exported to over HTTP with --tracing=otlp",
+		EnvVar: "OTLP_ENDPOINT",
+	})
+	maxDataAge := app.String(cli.StringOpt{
+		Name:   "max-data-age",
+		Value:  "",
+		Desc:   "Warn in the health checks when people were last loaded from TME longer ago than this duration, e.g. 48h. Empty never warns",
+		EnvVar: "MAX_DATA_AGE",
+	})
+	uuidVersion := app.String(cli.StringOpt{
+		Name:   "uuid-version",
+		Value:  "v3",
+		Desc:   "Version of the UUIDs derived from the TME ids: v3 (MD5) or v5 (SHA1)",
+		EnvVar: "UUID_VERSION",
+	})
+	uuidNamespace := app.String(cli.StringOpt{
+		Name:   "uuid-namespace",
+		Value:  "",
+		Desc:   "Namespace of the UUIDs derived from the TME ids: a UUID, or dns, url, oid or x500. Empty for none, as people have always been given",
+		EnvVar: "UUID_NAMESPACE",
+	})
+	uuidMigration := app.Bool(cli.BoolOpt{
+		Name:   "uuid-migration",
+		Value:  false,
+		Desc:   "Record the UUIDs people had with --previous-uuid-version and --previous-uuid-namespace in their alternativeIdentifiers.uuids, and serve them by either",
+		EnvVar: "UUID_MIGRATION",
+	})
+	previousUUIDVersion := app.String(cli.StringOpt{
+		Name:   "previous-uuid-version",
+		Value:  "v3",
+		Desc:   "Version of the UUIDs migrated from",
+		EnvVar: "PREVIOUS_UUID_VERSION",
+	})
+	previousUUIDNamespace := app.String(cli.StringOpt{
+		Name:   "previous-uuid-namespace",
+		Value:  "",
+		Desc:   "Namespace of the UUIDs migrated from",
+		EnvVar: "PREVIOUS_UUID_NAMESPACE",
+	})
+	overridesFile := app.String(cli.StringOpt{
+		Name:   "overrides-file",
+		Value:  "",
+		Desc:   "Json file of the overrides to the people loaded, e.g. the canonical UUIDs of duplicate people",
+		EnvVar: "OVERRIDES_FILE",
+	})
+	loadFromCache := app.Bool(cli.BoolOpt{
+		Name:   "load-from-cache",
+		Value:  false,
+		Desc:   "Serve the people already in the cache file on start, e.g. after a snapshot import, instead of loading them from TME",
+		EnvVar: "LOAD_FROM_CACHE",
+	})
+
 	tmeTaxonomyName := "PN"
 
+	app.Before = func() {
+		if err := configureLogging(*logLevel, *logFormat); err != nil {
+			log.Errorf("Error configuring the logs: %v", err.Error())
+			cli.Exit(1)
+		}
+	}
+
+	app.Command("snapshot", "Export or import an offline snapshot of the cache file", func(cmd *cli.Cmd) {
+		cmd.Command("export", "Write the cached people to a gzipped NDJSON snapshot", func(export *cli.Cmd) {
+			file := export.StringArg("FILE", "-", "Snapshot file, - for stdout")
+			export.Action = func() {
+				out := os.Stdout
+				if *file != "-" {
+					f, err := os.Create(*file)
+					if err != nil {
+						log.Errorf("Error creating snapshot: %v", err.Error())
+						cli.Exit(1)
+					}
+					out = f
+				}
+				err := people.ExportSnapshot(*cacheFileName, out)
+				//closed before exiting, as cli.Exit does not run deferred calls
+				if out != os.Stdout {
+					if closeErr := out.Close(); err == nil {
+						err = closeErr
+					}
+				}
+				if err != nil {
+					log.Errorf("Error exporting snapshot: %v", err.Error())
+					cli.Exit(1)
+				}
+			}
+		})
+		cmd.Command("import", "Load a snapshot into an empty cache file", func(imp *cli.Cmd) {
+			file := imp.StringArg("FILE", "-", "Snapshot file, - for stdin")
+			imp.Action = func() {
+				in := os.Stdin
+				if *file != "-" {
+					f, err := os.Open(*file)
+					if err != nil {
+						log.Errorf("Error opening snapshot: %v", err.Error())
+						cli.Exit(1)
+					}
+					in = f
+				}
+				count, err := people.ImportSnapshot(*cacheFileName, in)
+				if in != os.Stdin {
+					in.Close()
+				}
+				if err != nil {
+					log.Errorf("Error importing snapshot: %v", err.Error())
+					cli.Exit(1)
+				}
+				log.Printf("Imported %d people into %v", count, *cacheFileName)
+			}
+		})
+	})
+
 	app.Action = func() {
 		baseftrwapp.OutputMetricsIfRequired(*graphiteTCPAddress, *graphitePrefix, *logMetrics)
-		client := getResilientClient()
+		stopTracing, err := startTracing(*tracing, *otlpEndpoint)
+		if err != nil {
+			log.Errorf("Error starting the tracing: %v", err.Error())
+			cli.Exit(1)
+		}
+		defer stopTracing(context.Background())
 		modelTransformer := new(people.PersonTransformer)
+		if *fakeTME {
+			fake := faketme.NewServer(faketme.Config{Username: *username, Password: *password, Token: *token, Taxonomy: tmeTaxonomyName}, faketme.GeneratePeople(*fakeTMEPeople))
+			defer fake.Close()
+			log.Infof("Serving %d fake people from a fake TME on %v", *fakeTMEPeople, fake.URL)
+			*tmeBaseURL = fake.URL
+		}
+		repository, err := getRepository(*tmeFile, *tmeBaseURL, *username, *password, *token, *maxRecords, *batchSize, tmeTaxonomyName, modelTransformer)
+		if err != nil {
+			log.Errorf("Error creating the people repository: %v", err.Error())
+			cli.Exit(1)
+		}
+		options := []people.ServiceOption{people.WithRepositoryProbe(getRepositoryProbe(*tmeFile, *tmeBaseURL, *username, *password, *token, tmeTaxonomyName))}
+		if *maxDataAge != "" {
+			age, err := time.ParseDuration(*maxDataAge)
+			if err != nil {
+				log.Errorf("Error parsing the max data age: %v", err.Error())
+				cli.Exit(1)
+			}
+			options = append(options, people.WithMaxDataAge(age))
+		}
+		scheme, err := people.ParseUUIDScheme(*uuidVersion, *uuidNamespace)
+		if err != nil {
+			log.Errorf("Error parsing the UUID scheme: %v", err.Error())
+			cli.Exit(1)
+		}
+		options = append(options, people.WithUUIDScheme(scheme))
+		if *uuidMigration {
+			previous, err := people.ParseUUIDScheme(*previousUUIDVersion, *previousUUIDNamespace)
+			if err != nil {
+				log.Errorf("Error parsing the previous UUID scheme: %v", err.Error())
+				cli.Exit(1)
+			}
+			options = append(options, people.WithUUIDMigration(previous))
+		}
+		if *overridesFile != "" {
+			overrides, err := people.LoadOverrides(*overridesFile)
+			if err != nil {
+				log.Errorf("Error reading the overrides: %v", err.Error())
+				cli.Exit(1)
+			}
+			options = append(options, people.WithOverrides(overrides))
+		}
+		if *loadFromCache {
+			options = append(options, people.WithCachedDataOnStart())
+		}
+		if *maxShrinkPercent > 0 {
+			options = append(options, people.WithShrinkGuard(*maxShrinkPercent))
+		}
+		if *publishTo != "" {
+			sink, closeSink, err := getMessageSink(*publishTo, *kafkaProxyAddress, *kafkaTopic)
+			if err != nil {
+				log.Errorf("Error creating the publisher: %v", err.Error())
+				cli.Exit(1)
+			}
+			defer closeSink()
+			options = append(options, people.WithChangePublisher(sink))
+		}
+		if *conceptRWURL != "" {
+			options = append(options, people.WithConceptWriter(getResilientClient(), *conceptRWURL, *conceptRWConcurrency, *conceptRWOnlyChanged))
+		}
+		if len(*webhookURLs) > 0 {
+			options = append(options, people.WithWebhooks(getResilientClient(), *webhookURLs, *webhookSecret))
+		}
 		s := people.NewPeopleService(
-			tmereader.NewTmeRepository(
-				client,
-				*tmeBaseURL,
-				*username,
-				*password,
-				*token,
-				*maxRecords,
-				*batchSize,
-				tmeTaxonomyName,
-				&tmereader.AuthorityFiles{},
-				modelTransformer),
+			repository,
 			*baseURL,
 			tmeTaxonomyName,
 			*maxRecords,
-			*cacheFileName)
+			*cacheFileName,
+			options...)
 		defer s.Shutdown()
 		handler := people.NewPeopleHandler(s)
 		router(handler)
+		if *prometheusMetrics {
+			http.Handle("/metrics", prometheusHandler())
+		}
 
 		log.Printf("listening on %d", *port)
-		err := http.ListenAndServe(fmt.Sprintf(":%d", *port), nil)
+		err = http.ListenAndServe(fmt.Sprintf(":%d", *port), nil)
 		if err != nil {
 			log.Errorf("Error by listen and serve: %v", err.Error())
 		}
@@ -132,8 +416,52 @@ func main() {
 	app.Run(os.Args)
 }
 
+func getRepository(tmeFile string, tmeBaseURL string, username string, password string, token string, maxRecords int, batchSize int, taxonomyName string, modelTransformer *people.PersonTransformer) (tmereader.Repository, error) {
+	if tmeFile != "" {
+		log.Infof("Reading people from %v instead of TME", tmeFile)
+		return people.NewFileRepository(tmeFile, maxRecords, modelTransformer)
+	}
+	return tmereader.NewTmeRepository(
+		getResilientClient(),
+		tmeBaseURL,
+		username,
+		password,
+		token,
+		maxRecords,
+		batchSize,
+		taxonomyName,
+		&tmereader.AuthorityFiles{},
+		modelTransformer), nil
+}
+
+//getRepositoryProbe checks the repository without the retries of the resilient client, so the health checks answer quickly
+func getRepositoryProbe(tmeFile string, tmeBaseURL string, username string, password string, token string, taxonomyName string) people.Probe {
+	if tmeFile != "" {
+		return people.NewFileProbe(tmeFile)
+	}
+	return people.NewTMEProbe(&http.Client{Timeout: 10 * time.Second}, tmeBaseURL, username, password, token, taxonomyName)
+}
+
+func getMessageSink(publishTo string, kafkaProxyAddress string, kafkaTopic string) (people.MessageSink, func() error, error) {
+	noClose := func() error { return nil }
+	switch {
+	case publishTo == "kafka":
+		return people.NewKafkaProxySink(getResilientClient(), kafkaProxyAddress, kafkaTopic), noClose, nil
+	case publishTo == "stdout":
+		return people.NewWriterSink(os.Stdout), noClose, nil
+	case strings.HasPrefix(publishTo, "file:"):
+		f, err := os.OpenFile(strings.TrimPrefix(publishTo, "file:"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, nil, err
+		}
+		return people.NewWriterSink(f), f.Close, nil
+	}
+	return nil, nil, fmt.Errorf("Unsupported publish destination [%v], expected one of: kafka, stdout, file:{path}", publishTo)
+}
+
 func router(handler people.PeopleHandler) {
 	servicesRouter := mux.NewRouter()
+	servicesRouter.Use(people.TracingHandler)
 
 	getPeopleSubrouter := servicesRouter.Path("/transformers/people").Subrouter()
 	getPeopleSubrouter.Methods("GET").HandlerFunc(handler.GetPeople)
@@ -147,6 +475,35 @@ func router(handler people.PeopleHandler) {
 	personIDsSubrouter.Methods("GET").HandlerFunc(handler.GetPeopleUUIDs)
 	personIDsSubrouter.NewRoute().HandlerFunc(handler.OnlyGetAllowed)
 
+	personLinksSubrouter := servicesRouter.Path("/transformers/people/__links").Subrouter()
+	personLinksSubrouter.Methods("GET").HandlerFunc(handler.GetPeopleLinks)
+	personLinksSubrouter.NewRoute().HandlerFunc(handler.OnlyGetAllowed)
+
+	exportSubrouter := servicesRouter.Path("/transformers/people/__export").Subrouter()
+	exportSubrouter.Methods("GET").HandlerFunc(handler.Export)
+	exportSubrouter.NewRoute().HandlerFunc(handler.OnlyGetAllowed)
+
+	diffSubrouter := servicesRouter.Path("/transformers/people/__diff").Subrouter()
+	diffSubrouter.Methods("GET").HandlerFunc(handler.GetDiff)
+	diffSubrouter.NewRoute().HandlerFunc(handler.OnlyGetAllowed)
+
+	errorsSubrouter := servicesRouter.Path("/transformers/people/__errors").Subrouter()
+	errorsSubrouter.Methods("GET").HandlerFunc(handler.GetErrors)
+	errorsSubrouter.NewRoute().HandlerFunc(handler.OnlyGetAllowed)
+
+	qualitySubrouter := servicesRouter.Path("/transformers/people/__quality").Subrouter()
+	qualitySubrouter.Methods("GET").HandlerFunc(handler.GetQuality)
+	qualitySubrouter.NewRoute().HandlerFunc(handler.OnlyGetAllowed)
+
+	duplicatesSubrouter := servicesRouter.Path("/transformers/people/__duplicates").Subrouter()
+	duplicatesSubrouter.Methods("GET").HandlerFunc(handler.GetDuplicates)
+	duplicatesSubrouter.NewRoute().HandlerFunc(handler.OnlyGetAllowed)
+
+	snapshotSubrouter := servicesRouter.Path("/transformers/people/__snapshot").Subrouter()
+	snapshotSubrouter.Methods("GET").HandlerFunc(handler.GetSnapshot)
+	snapshotSubrouter.Methods("PUT").HandlerFunc(handler.PutSnapshot)
+	snapshotSubrouter.NewRoute().HandlerFunc(handler.OnlyGetOrPutAllowed)
+
 	personByUUIDSubrouter := servicesRouter.Path("/transformers/people/{uuid:([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})}").Subrouter()
 	personByUUIDSubrouter.Methods("GET").HandlerFunc(handler.GetPersonByUUID)
 	personByUUIDSubrouter.NewRoute().HandlerFunc(handler.OnlyGetAllowed)
@@ -164,13 +521,63 @@ func router(handler people.PeopleHandler) {
 	http.HandleFunc(status.BuildInfoPath, status.BuildInfoHandler)
 	http.HandleFunc(status.BuildInfoPathDW, status.BuildInfoHandler)
 
-	http.HandleFunc("/__health", v1a.Handler("V1 People Transformer Healthchecks", "Checks for the health of the service", handler.HealthCheck()))
+	http.HandleFunc("/__health", v1a.Handler("V1 People Transformer Healthchecks", "Checks for the health of the service", handler.HealthChecks()...))
 
 	g2gHandler := status.NewGoodToGoHandler(gtg.StatusChecker(handler.G2GCheck))
 	http.HandleFunc(status.GTGPath, g2gHandler)
 	http.Handle("/", monitoringRouter)
 }
 
+//configureLogging logs at the level given, as json so the fields of the events can be queried
+func configureLogging(level string, format string) error {
+	parsed, err := log.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case "json":
+		log.SetFormatter(&log.JSONFormatter{})
+	case "text":
+		log.SetFormatter(&log.TextFormatter{})
+	default:
+		return fmt.Errorf("Unknown log format %v, expected json or text", format)
+	}
+	log.SetLevel(parsed)
+	return nil
+}
+
+//startTracing exports the spans to stdout or to an OTLP collector, returning the function flushing them on shutdown
+func startTracing(exporter string, otlpEndpoint string) (func(context.Context) error, error) {
+	var spanExporter sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case "":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		spanExporter, err = stdouttrace.New()
+	case "otlp":
+		spanExporter, err = otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(otlpEndpoint))
+	default:
+		return nil, fmt.Errorf("Unknown tracing exporter %v, expected stdout or otlp", exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", "v1-people-transformer"))))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider.Shutdown, nil
+}
+
+//prometheusHandler serves the metrics of the go-metrics registry, with the ones of the Go runtime and the process
+func prometheusHandler() http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(people.NewMetricsCollector(metrics.DefaultRegistry), collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
 func getResilientClient() *pester.Client {
 	tr := &http.Transport{
 		MaxIdleConnsPerHost: 32,
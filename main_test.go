@@ -0,0 +1,106 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/v1-people-transformer/faketme"
+	"github.com/Financial-Times/v1-people-transformer/people"
+	log "github.com/Sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadPeopleFromFakeTME(t *testing.T) {
+	fake := faketme.NewServer(faketme.Config{Username: "user", Password: "pass", Token: "token", Taxonomy: "PN"}, faketme.GeneratePeople(5))
+	defer fake.Close()
+	fake.FailNext(1, http.StatusServiceUnavailable)
+
+	handler, shutdown := newTestHandler(t, fake.URL, "pass")
+	defer shutdown()
+	waitForCount(t, handler, 5)
+
+	rec := httptest.NewRecorder()
+	handler.GetPeople(rec, httptest.NewRequest("GET", "/transformers/people", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 5, strings.Count(rec.Body.String(), "\n"))
+	assert.Contains(t, rec.Body.String(), `"prefLabel":"Fake Person 5"`)
+	assert.True(t, handler.G2GCheck().GoodToGo)
+}
+
+func TestLoadPeopleFromFakeTMEWithWrongCredentials(t *testing.T) {
+	fake := faketme.NewServer(faketme.Config{Username: "user", Password: "pass", Token: "token", Taxonomy: "PN"}, faketme.GeneratePeople(5))
+	defer fake.Close()
+
+	handler, shutdown := newTestHandler(t, fake.URL, "wrong")
+	defer shutdown()
+	for i := 0; i < 100 && fake.Requests() == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, 1, fake.Requests(), "Unauthorised requests are not retried")
+	assert.False(t, handler.G2GCheck().GoodToGo)
+}
+
+func TestProbeFakeTME(t *testing.T) {
+	fake := faketme.NewServer(faketme.Config{Username: "user", Password: "pass", Token: "token", Taxonomy: "PN"}, faketme.GeneratePeople(5))
+
+	assert.NoError(t, getRepositoryProbe("", fake.URL, "user", "pass", "token", "PN")())
+	assert.EqualError(t, getRepositoryProbe("", fake.URL, "user", "wrong", "token", "PN")(), "TME rejected the credentials with status 401")
+	fake.FailNext(1, http.StatusServiceUnavailable)
+	assert.EqualError(t, getRepositoryProbe("", fake.URL, "user", "pass", "token", "PN")(), "TME returned status 503")
+
+	fake.Close()
+	err := getRepositoryProbe("", fake.URL, "user", "pass", "token", "PN")()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "TME is unreachable")
+	}
+}
+
+func TestPrometheusMetrics(t *testing.T) {
+	rec := httptest.NewRecorder()
+	prometheusHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "people_load_duration_seconds")
+	assert.Contains(t, rec.Body.String(), "people_dataset_size")
+	assert.Contains(t, rec.Body.String(), "go_goroutines")
+}
+
+func TestConfigureLogging(t *testing.T) {
+	defer configureLogging("info", "text")
+	assert.NoError(t, configureLogging("debug", "json"))
+	assert.Equal(t, log.DebugLevel, log.GetLevel())
+	assert.EqualError(t, configureLogging("info", "xml"), "Unknown log format xml, expected json or text")
+	assert.Error(t, configureLogging("chatty", "json"))
+}
+
+func newTestHandler(t *testing.T, tmeBaseURL string, password string) (people.PeopleHandler, func()) {
+	cache, err := ioutil.TempFile("", "main_test")
+	assert.NoError(t, err)
+	assert.NoError(t, cache.Close())
+
+	repository, err := getRepository("", tmeBaseURL, "user", password, "token", 2, 1, "PN", new(people.PersonTransformer))
+	assert.NoError(t, err)
+	s := people.NewPeopleService(repository, "http://localhost:8080/transformers/people/", "PN", 2, cache.Name())
+	return people.NewPeopleHandler(s), func() {
+		s.Shutdown()
+		os.Remove(cache.Name())
+	}
+}
+
+func waitForCount(t *testing.T, handler people.PeopleHandler, expected int) {
+	var count string
+	for i := 0; i < 1000; i++ {
+		rec := httptest.NewRecorder()
+		handler.GetCount(rec, httptest.NewRequest("GET", "/transformers/people/__count", nil))
+		if count = rec.Body.String(); count == strconv.Itoa(expected) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Fail(t, "People not loaded", "Count was %v, expected %v", count, expected)
+}
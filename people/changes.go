@@ -0,0 +1,136 @@
+package people
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/boltdb/bolt"
+)
+
+type changeType string
+
+const (
+	personAdded   changeType = "added"
+	personChanged changeType = "changed"
+	personRemoved changeType = "removed"
+	//personMigrated is a person no longer served by its previous UUID, but by the one it migrated to
+	personMigrated changeType = "migrated"
+)
+
+//personChange is a person added, changed, removed or migrated by a load, with the stored json of the added, changed and migrated ones
+type personChange struct {
+	uuid             string
+	change           changeType
+	marshalledPerson []byte
+	migratedTo       string
+}
+
+//loadResult describes a finished load to the load listeners, changes are only known for a successful load
+type loadResult struct {
+	loadID        string
+	transactionID string
+	started       time.Time
+	duration      time.Duration
+	err           error
+	count         int
+	generation    int
+	changes       []personChange
+}
+
+//logger correlates the events logged about the load
+func (r loadResult) logger() *log.Entry {
+	return log.WithFields(log.Fields{"load_id": r.loadID, "transaction_id": r.transactionID})
+}
+
+func (r loadResult) countOf(change changeType) int {
+	count := 0
+	for _, c := range r.changes {
+		if c.change == change {
+			count++
+		}
+	}
+	return count
+}
+
+//loadListener is told about every load once all its people are stored
+type loadListener interface {
+	loadFinished(result loadResult)
+}
+
+//listenerQueue tells its listener about the loads in order and one at a time, in the background so a slow listener holds up neither the loads nor the other listeners
+type listenerQueue struct {
+	sync.Mutex
+	listener loadListener
+	pending  []loadResult
+	draining bool
+}
+
+func (q *listenerQueue) push(load loadResult) {
+	q.Lock()
+	defer q.Unlock()
+	q.pending = append(q.pending, load)
+	if !q.draining {
+		q.draining = true
+		go q.drain()
+	}
+}
+
+func (q *listenerQueue) drain() {
+	for {
+		q.Lock()
+		if len(q.pending) == 0 {
+			q.draining = false
+			q.Unlock()
+			return
+		}
+		load := q.pending[0]
+		q.pending = q.pending[1:]
+		q.Unlock()
+		q.listener.loadFinished(load)
+	}
+}
+
+//diffGenerations compares the people of two generations, a missing generation has no people.
+//The people whose previous UUID is an alias of the current generation are migrated rather than removed
+func diffGenerations(tx *bolt.Tx, previous cacheGeneration, current cacheGeneration) []personChange {
+	var changes []personChange
+	people := tx.Bucket([]byte(current.people))
+	hashes := tx.Bucket([]byte(current.hashes))
+	aliases := tx.Bucket([]byte(current.aliases))
+	previousHashes := tx.Bucket([]byte(previous.hashes))
+	if hashes != nil && people != nil {
+		hashes.ForEach(func(k, v []byte) error {
+			var previousHash []byte
+			if previousHashes != nil {
+				previousHash = previousHashes.Get(k)
+			}
+			if bytes.Equal(previousHash, v) {
+				return nil
+			}
+			change := personChange{uuid: string(k), change: personAdded, marshalledPerson: append([]byte(nil), people.Get(k)...)}
+			if previousHash != nil {
+				change.change = personChanged
+			}
+			changes = append(changes, change)
+			return nil
+		})
+	}
+	if previousHashes != nil {
+		previousHashes.ForEach(func(k, v []byte) error {
+			if hashes != nil && hashes.Get(k) != nil {
+				return nil
+			}
+			if aliases != nil && people != nil {
+				if to := aliases.Get(k); to != nil && people.Get(to) != nil {
+					changes = append(changes, personChange{uuid: string(k), change: personMigrated, marshalledPerson: append([]byte(nil), people.Get(to)...), migratedTo: string(to)})
+					return nil
+				}
+			}
+			changes = append(changes, personChange{uuid: string(k), change: personRemoved})
+			return nil
+		})
+	}
+	return changes
+}
@@ -0,0 +1,144 @@
+package people
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadListenersAreToldTheChanges(t *testing.T) {
+	tmpfile := getTempFile(t)
+	defer os.Remove(tmpfile.Name())
+	repo := dummyRepo{terms: []term{{CanonicalName: "Bob", RawID: "bob"}, {CanonicalName: "Fred", RawID: "fred"}}}
+	listener := &recordingListener{}
+	service := NewPeopleService(&repo, "/base/url", "taxonomy_string", 1, tmpfile.Name(), withLoadListener(listener))
+	defer service.Shutdown()
+	waitTillInit(t, service)
+	waitTillDataLoaded(t, service)
+
+	first := listener.waitForLoads(t, 1)
+	assert.NoError(t, first.err)
+	assert.Equal(t, 2, first.count)
+	assert.Equal(t, 1, first.generation)
+	assert.Equal(t, 2, first.countOf(personAdded))
+	assert.NotEmpty(t, first.transactionID)
+
+	repo.terms = []term{{CanonicalName: "Frederick", RawID: "fred"}, {CanonicalName: "Third", RawID: "third"}}
+	repo.count = 0
+	assert.NoError(t, service.reloadDB(context.Background()))
+	second := listener.waitForLoads(t, 2)
+	assert.NoError(t, second.err)
+	assert.Equal(t, 2, second.count)
+	assert.Equal(t, 2, second.generation)
+	var changes []changeType
+	for _, c := range second.changes {
+		changes = append(changes, c.change)
+		switch c.uuid {
+		case "28d66fcc-bb56-363d-80c1-f2d957ef58cf":
+			assert.Equal(t, personChanged, c.change)
+			assert.Contains(t, string(c.marshalledPerson), "Frederick")
+		case "be2e7e2b-0fa2-3969-a69b-74c46e754032":
+			assert.Equal(t, personRemoved, c.change)
+			assert.Nil(t, c.marshalledPerson)
+		default:
+			assert.Equal(t, personAdded, c.change)
+		}
+	}
+	assert.Len(t, changes, 3)
+
+	repo.count = 0
+	assert.NoError(t, service.reloadDB(context.Background()))
+	unchanged := listener.waitForLoads(t, 3)
+	assert.NoError(t, unchanged.err)
+	assert.Empty(t, unchanged.changes)
+
+	repo.count = 0
+	repo.err = errors.New("TME is down")
+	assert.Error(t, service.reloadDB(context.Background()))
+	failed := listener.waitForLoads(t, 4)
+	assert.Equal(t, repo.err, failed.err)
+	assert.Nil(t, failed.changes)
+}
+
+func TestSlowListenersDoNotHoldUpLoads(t *testing.T) {
+	tmpfile := getTempFile(t)
+	defer os.Remove(tmpfile.Name())
+	repo := dummyRepo{terms: []term{{CanonicalName: "Bob", RawID: "bob"}}}
+	slow := &blockingListener{unblock: make(chan struct{})}
+	listener := &recordingListener{}
+	service := NewPeopleService(&repo, "/base/url", "taxonomy_string", 1, tmpfile.Name(), withLoadListener(slow), thenLoadListener(&slow.recordingListener), withLoadListener(listener))
+	defer service.Shutdown()
+	first := listener.waitForLoads(t, 1)
+
+	repo.count = 0
+	assert.NoError(t, service.reloadDB(context.Background()))
+	second := listener.waitForLoads(t, 2)
+	assert.Empty(t, slow.loads, "The slow listener is still told about the first load")
+
+	close(slow.unblock)
+	assert.Equal(t, first.loadID, slow.waitForLoads(t, 1).loadID)
+	assert.Equal(t, second.loadID, slow.waitForLoads(t, 2).loadID, "The loads are told in order")
+}
+
+func withLoadListener(listener loadListener) ServiceOption {
+	return func(s *peopleServiceImpl) {
+		s.loadListeners = append(s.loadListeners, listener)
+	}
+}
+
+//thenLoadListener tells the listener about each load once the listener added before it is done with it
+func thenLoadListener(listener loadListener) ServiceOption {
+	return func(s *peopleServiceImpl) {
+		last := len(s.loadListeners) - 1
+		s.loadListeners[last] = listenerChain{s.loadListeners[last], listener}
+	}
+}
+
+type listenerChain []loadListener
+
+func (c listenerChain) loadFinished(load loadResult) {
+	for _, listener := range c {
+		listener.loadFinished(load)
+	}
+}
+
+type blockingListener struct {
+	recordingListener
+	unblock chan struct{}
+}
+
+func (l *blockingListener) loadFinished(load loadResult) {
+	<-l.unblock
+}
+
+type recordingListener struct {
+	sync.Mutex
+	loads []loadResult
+}
+
+func (l *recordingListener) loadFinished(load loadResult) {
+	l.Lock()
+	defer l.Unlock()
+	l.loads = append(l.loads, load)
+}
+
+//waitForLoads is needed as the listeners are told about the loads in the background
+func (l *recordingListener) waitForLoads(t *testing.T, n int) loadResult {
+	for i := 0; i < 1000; i++ {
+		l.Lock()
+		if len(l.loads) >= n {
+			load := l.loads[n-1]
+			l.Unlock()
+			return load
+		}
+		l.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.FailNow(t, "Load listener not called", "Expected %v loads", n)
+	return loadResult{}
+}
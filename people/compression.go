@@ -0,0 +1,113 @@
+package people
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	encodingIdentity = "identity"
+	encodingGzip     = "gzip"
+	encodingZstd     = "zstd"
+)
+
+//supportedEncodings in order of preference when the client weights them equally
+var supportedEncodings = []string{encodingZstd, encodingGzip}
+
+//negotiateEncoding picks the content coding for a streamed response from the Accept-Encoding header
+func negotiateEncoding(writer http.ResponseWriter, req *http.Request) string {
+	writer.Header().Add("Vary", "Accept-Encoding")
+	weights := parseQualityValues(req.Header.Get("Accept-Encoding"))
+
+	chosen, chosenWeight := encodingIdentity, 0.0
+	for _, encoding := range supportedEncodings {
+		weight, listed := weights[encoding]
+		if !listed {
+			weight = weights["*"]
+		}
+		if weight > chosenWeight {
+			chosen, chosenWeight = encoding, weight
+		}
+	}
+	return chosen
+}
+
+//parseQualityValues reads the weight of each entry of an Accept or Accept-Encoding header
+func parseQualityValues(header string) map[string]float64 {
+	weights := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		value := strings.ToLower(strings.TrimSpace(fields[0]))
+		if value == "" {
+			continue
+		}
+		weight := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+					weight = q
+				}
+			}
+		}
+		weights[value] = weight
+	}
+	return weights
+}
+
+//variantETag gives each content coding and representation its own strong validator
+func variantETag(etag string, variants ...string) string {
+	if etag == "" {
+		return etag
+	}
+	for _, variant := range variants {
+		if variant != "" && variant != encodingIdentity {
+			etag = strings.TrimSuffix(etag, "\"") + "-" + variant + "\""
+		}
+	}
+	return etag
+}
+
+func newEncoder(w io.Writer, encoding string) (io.WriteCloser, error) {
+	switch encoding {
+	case encodingGzip:
+		return gzip.NewWriter(w), nil
+	case encodingZstd:
+		return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1), zstd.WithLowerEncoderMem(true))
+	}
+	return nopWriteCloser{w}, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+//writeStream copies the stream to the response through the negotiated encoder, so nothing is buffered beyond the encoder window
+func writeStream(writer http.ResponseWriter, body io.Reader, encoding string) {
+	encoder, err := newEncoder(writer, encoding)
+	if err != nil {
+		writeJSONMessageWithStatus(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if encoding != encodingIdentity {
+		writer.Header().Set("Content-Encoding", encoding)
+		writer.Header().Del("Content-Length")
+	}
+	writer.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(encoder, body); err != nil {
+		log.Errorf("ERROR streaming response: %v", err.Error())
+	}
+	if err := encoder.Close(); err != nil {
+		log.Errorf("ERROR closing %v encoder: %v", encoding, err.Error())
+	}
+}
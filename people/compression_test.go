@@ -0,0 +1,108 @@
+package people
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		expected       string
+	}{
+		{"", encodingIdentity},
+		{"identity", encodingIdentity},
+		{"gzip", encodingGzip},
+		{"gzip, deflate, br", encodingGzip},
+		{"zstd", encodingZstd},
+		{"gzip, zstd", encodingZstd},
+		{"gzip;q=1.0, zstd;q=0.5", encodingGzip},
+		{"gzip;q=0, zstd;q=0", encodingIdentity},
+		{"*", encodingZstd},
+		{"*;q=0.5, zstd;q=0", encodingGzip},
+		{"GZIP", encodingGzip},
+	}
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		req := newRequest("GET", "/transformers/people")
+		req.Header.Set("Accept-Encoding", test.acceptEncoding)
+		assert.Equal(t, test.expected, negotiateEncoding(rec, req), test.acceptEncoding)
+		assert.Contains(t, rec.Header()["Vary"], "Accept-Encoding")
+	}
+}
+
+func TestVariantETag(t *testing.T) {
+	assert.Equal(t, `"abc"`, variantETag(`"abc"`, encodingIdentity))
+	assert.Equal(t, `"abc-gzip"`, variantETag(`"abc"`, encodingGzip))
+	assert.Equal(t, `"abc-zstd"`, variantETag(`"abc"`, encodingZstd))
+	assert.Equal(t, `"abc-v2-gzip"`, variantETag(`"abc"`, "v2", encodingGzip))
+	assert.Equal(t, `"abc"`, variantETag(`"abc"`, "", encodingIdentity))
+	assert.Equal(t, "", variantETag("", encodingGzip))
+}
+
+func TestCompressedGetPeople(t *testing.T) {
+	s := &dummyService{
+		initialised: true,
+		count:       2,
+		people:      []person{{UUID: testUUID}, {UUID: testUUID2}}}
+
+	rec := httptest.NewRecorder()
+	req := newRequest("GET", "/transformers/people")
+	req.Header.Set("Accept-Encoding", "gzip")
+	router(s).ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	gz, err := gzip.NewReader(rec.Body)
+	assert.NoError(t, err)
+	body, err := ioutil.ReadAll(gz)
+	assert.NoError(t, err)
+	assert.Equal(t, getPeopleResponse, string(body))
+
+	rec = httptest.NewRecorder()
+	req = newRequest("GET", "/transformers/people/__ids")
+	req.Header.Set("Accept-Encoding", "zstd")
+	router(s).ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "zstd", rec.Header().Get("Content-Encoding"))
+	zr, err := zstd.NewReader(rec.Body)
+	assert.NoError(t, err)
+	defer zr.Close()
+	body, err = ioutil.ReadAll(zr)
+	assert.NoError(t, err)
+	assert.Equal(t, getPeopleByUUIDResponse, string(body))
+}
+
+func TestCompressedConditionalGetPeople(t *testing.T) {
+	s := &dummyService{
+		initialised: true,
+		dataLoaded:  true,
+		count:       1,
+		people:      []person{{UUID: testUUID}},
+		dataset:     datasetVersion{generation: 1, etag: `"dataset"`}}
+
+	rec := httptest.NewRecorder()
+	req := newRequest("GET", "/transformers/people")
+	req.Header.Set("Accept-Encoding", "gzip")
+	router(s).ServeHTTP(rec, req)
+	assert.Equal(t, `"dataset-gzip"`, rec.Header().Get("ETag"))
+
+	rec = httptest.NewRecorder()
+	req = newRequest("GET", "/transformers/people")
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("If-None-Match", `"dataset-gzip"`)
+	router(s).ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Contains(t, rec.Header()["Vary"], "Accept-Encoding")
+
+	rec = httptest.NewRecorder()
+	req = newRequest("GET", "/transformers/people")
+	req.Header.Set("If-None-Match", `"dataset-gzip"`)
+	router(s).ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code, "An uncompressed representation has a different validator")
+}
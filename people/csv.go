@@ -0,0 +1,68 @@
+package people
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+//csvListSeparator joins multi-valued fields into a single cell
+const csvListSeparator = "|"
+
+var csvColumns = map[string]func(p person) string{
+	"uuid":          func(p person) string { return p.UUID },
+	"prefLabel":     func(p person) string { return p.PrefLabel },
+	"tmeIdentifier": func(p person) string { return strings.Join(p.AlternativeIdentifiers.TME, csvListSeparator) },
+	"aliases":       func(p person) string { return strings.Join(p.Aliases, csvListSeparator) },
+}
+
+var defaultCSVColumns = []string{"uuid", "prefLabel", "tmeIdentifier", "aliases"}
+
+//newCSVExport writes the columns listed in the columns query parameter, or all of them, under a header row
+func newCSVExport(query url.Values) (exportFormat, error) {
+	columns := append([]string(nil), defaultCSVColumns...)
+	if selected := query.Get("columns"); selected != "" {
+		columns = strings.Split(selected, ",")
+	}
+	for i, column := range columns {
+		columns[i] = strings.TrimSpace(column)
+		if _, supported := csvColumns[columns[i]]; !supported {
+			return exportFormat{}, fmt.Errorf("Unsupported csv column [%v], expected some of: %v", columns[i], strings.Join(defaultCSVColumns, ", "))
+		}
+	}
+
+	header, err := csvRecord(columns)
+	if err != nil {
+		return exportFormat{}, err
+	}
+	return exportFormat{
+		contentType: "text/csv; charset=utf-8",
+		filename:    "people.csv",
+		preamble:    header,
+		render: func(w io.Writer, p person) error {
+			record := make([]string, len(columns))
+			for i, column := range columns {
+				record[i] = csvColumns[column](p)
+			}
+			row, err := csvRecord(record)
+			if err != nil {
+				return err
+			}
+			_, err = io.WriteString(w, row)
+			return err
+		},
+	}, nil
+}
+
+func csvRecord(record []string) (string, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	if err := w.Write(record); err != nil {
+		return "", err
+	}
+	w.Flush()
+	return b.String(), w.Error()
+}
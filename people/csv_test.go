@@ -0,0 +1,55 @@
+package people
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVExport(t *testing.T) {
+	s := &dummyService{
+		initialised: true,
+		count:       2,
+		people: []person{
+			{UUID: testUUID, PrefLabel: "Smith, John", Aliases: []string{"John Smith", "J. Smith"}, AlternativeIdentifiers: alternativeIdentifiers{TME: []string{"MTIz-UE4="}}},
+			{UUID: testUUID2, PrefLabel: "Fred \"Freddie\" Jones"},
+		}}
+
+	rec := httptest.NewRecorder()
+	router(s).ServeHTTP(rec, newRequest("GET", "/transformers/people/__export?format=csv"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "attachment; filename=\"people.csv\"", rec.Header().Get("Content-Disposition"))
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"uuid", "prefLabel", "tmeIdentifier", "aliases"},
+		{testUUID, "Smith, John", "MTIz-UE4=", "John Smith|J. Smith"},
+		{testUUID2, "Fred \"Freddie\" Jones", "", ""},
+	}, records)
+
+	rec = httptest.NewRecorder()
+	router(s).ServeHTTP(rec, newRequest("GET", "/transformers/people/__export?format=csv&columns=prefLabel,%20uuid"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	records, err = csv.NewReader(rec.Body).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"prefLabel", "uuid"},
+		{"Smith, John", testUUID},
+		{"Fred \"Freddie\" Jones", testUUID2},
+	}, records)
+
+	rec = httptest.NewRecorder()
+	router(s).ServeHTTP(rec, newRequest("GET", "/transformers/people/__export?format=csv&columns=uuid,dateOfBirth"))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "{\"message\": \"Unsupported csv column [dateOfBirth], expected some of: uuid, prefLabel, tmeIdentifier, aliases\"}\n", rec.Body.String())
+}
+
+func TestCSVExportKeepsDefaultColumns(t *testing.T) {
+	_, err := newCSVExport(map[string][]string{"columns": {" uuid "}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"uuid", "prefLabel", "tmeIdentifier", "aliases"}, defaultCSVColumns)
+}
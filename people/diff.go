@@ -0,0 +1,110 @@
+package people
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/boltdb/bolt"
+)
+
+//datasetDiff lists the people added, removed, changed and migrated to another UUID since the previous load
+type datasetDiff struct {
+	CurrentCount  int               `json:"currentCount"`
+	PreviousCount int               `json:"previousCount"`
+	Added         []string          `json:"added"`
+	Removed       []string          `json:"removed"`
+	Changed       []personDiff      `json:"changed"`
+	Migrated      []personMigration `json:"migrated"`
+}
+
+type personDiff struct {
+	UUID   string      `json:"uuid"`
+	Fields []fieldDiff `json:"fields"`
+}
+
+type personMigration struct {
+	UUID       string `json:"uuid"`
+	MigratedTo string `json:"migratedTo"`
+}
+
+//fieldDiff names nested fields by their path, e.g. alternativeIdentifiers.TME
+type fieldDiff struct {
+	Field    string      `json:"field"`
+	Previous interface{} `json:"previous"`
+	Current  interface{} `json:"current"`
+}
+
+func newDatasetDiff(tx *bolt.Tx, previous cacheGeneration, current cacheGeneration) (datasetDiff, error) {
+	diff := datasetDiff{Added: []string{}, Removed: []string{}, Changed: []personDiff{}, Migrated: []personMigration{}}
+	if bucket := tx.Bucket([]byte(current.people)); bucket != nil {
+		diff.CurrentCount = bucket.Stats().KeyN
+	}
+	previousPeople := tx.Bucket([]byte(previous.people))
+	if previousPeople != nil {
+		diff.PreviousCount = previousPeople.Stats().KeyN
+	}
+
+	for _, change := range diffGenerations(tx, previous, current) {
+		switch change.change {
+		case personAdded:
+			diff.Added = append(diff.Added, change.uuid)
+		case personRemoved:
+			diff.Removed = append(diff.Removed, change.uuid)
+		case personChanged:
+			fields, err := diffFields(previousPeople.Get([]byte(change.uuid)), change.marshalledPerson)
+			if err != nil {
+				return datasetDiff{}, err
+			}
+			diff.Changed = append(diff.Changed, personDiff{UUID: change.uuid, Fields: fields})
+		case personMigrated:
+			diff.Migrated = append(diff.Migrated, personMigration{UUID: change.uuid, MigratedTo: change.migratedTo})
+		}
+	}
+	return diff, nil
+}
+
+func diffFields(previousPerson []byte, currentPerson []byte) ([]fieldDiff, error) {
+	previous, current := map[string]interface{}{}, map[string]interface{}{}
+	for _, p := range []struct {
+		marshalled []byte
+		fields     map[string]interface{}
+	}{{previousPerson, previous}, {currentPerson, current}} {
+		var decoded map[string]interface{}
+		if err := json.Unmarshal(p.marshalled, &decoded); err != nil {
+			return nil, err
+		}
+		flattenFields("", decoded, p.fields)
+	}
+
+	names := map[string]bool{}
+	for name := range previous {
+		names[name] = true
+	}
+	for name := range current {
+		names[name] = true
+	}
+	var sorted []string
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	fields := []fieldDiff{}
+	for _, name := range sorted {
+		if !reflect.DeepEqual(previous[name], current[name]) {
+			fields = append(fields, fieldDiff{Field: name, Previous: previous[name], Current: current[name]})
+		}
+	}
+	return fields, nil
+}
+
+func flattenFields(prefix string, object map[string]interface{}, fields map[string]interface{}) {
+	for name, value := range object {
+		if nested, isObject := value.(map[string]interface{}); isObject {
+			flattenFields(prefix+name+".", nested, fields)
+			continue
+		}
+		fields[prefix+name] = value
+	}
+}
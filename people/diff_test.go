@@ -0,0 +1,64 @@
+package people
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatasetDiff(t *testing.T) {
+	tmpfile := getTempFile(t)
+	defer os.Remove(tmpfile.Name())
+	repo := dummyRepo{terms: []term{{CanonicalName: "Bob", RawID: "bob"}, {CanonicalName: "Fred", RawID: "fred", Aliases: aliases{Alias: []alias{{Name: "F"}}}}}}
+	service := createTestPeopleService(&repo, tmpfile.Name())
+	defer service.Shutdown()
+	waitTillInit(t, service)
+	waitTillDataLoaded(t, service)
+
+	diff, available, err := service.getDatasetDiff()
+	assert.NoError(t, err)
+	assert.True(t, available)
+	assert.Equal(t, 2, diff.CurrentCount)
+	assert.Equal(t, 0, diff.PreviousCount)
+	assert.Equal(t, []string{fredUUID, bobUUID}, diff.Added)
+
+	repo.terms = []term{{CanonicalName: "Frederick", RawID: "fred", Aliases: aliases{Alias: []alias{{Name: "F"}, {Name: "Freddie"}}}}, {CanonicalName: "Third", RawID: "third"}}
+	repo.count = 0
+	assert.NoError(t, service.reloadDB(context.Background()))
+	waitTillDataLoaded(t, service)
+
+	diff, available, err = service.getDatasetDiff()
+	assert.NoError(t, err)
+	assert.True(t, available)
+	assert.Equal(t, 2, diff.CurrentCount)
+	assert.Equal(t, 2, diff.PreviousCount)
+	assert.Len(t, diff.Added, 1)
+	assert.Equal(t, []string{bobUUID}, diff.Removed)
+	assert.Equal(t, []personDiff{{UUID: fredUUID, Fields: []fieldDiff{
+		{Field: "aliases", Previous: []interface{}{"F"}, Current: []interface{}{"F", "Freddie"}},
+		{Field: "name", Previous: "Fred", Current: "Frederick"},
+		{Field: "prefLabel", Previous: "Fred", Current: "Frederick"},
+	}}}, diff.Changed)
+
+	p, found, err := service.getPersonByUUID(fredUUID)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "Frederick", p.PrefLabel)
+	_, found, err = service.getPersonByUUID(bobUUID)
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestDiffFields(t *testing.T) {
+	fields, err := diffFields([]byte(`{"uuid":"a","alternativeIdentifiers":{"TME":["x"],"uuids":["a"]}}`), []byte(`{"uuid":"a","alternativeIdentifiers":{"TME":["y"],"uuids":["a"]},"type":"Person"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []fieldDiff{
+		{Field: "alternativeIdentifiers.TME", Previous: []interface{}{"x"}, Current: []interface{}{"y"}},
+		{Field: "type", Previous: nil, Current: "Person"},
+	}, fields)
+
+	_, err = diffFields([]byte(`{`), []byte(`{}`))
+	assert.Error(t, err)
+}
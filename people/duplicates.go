@@ -0,0 +1,165 @@
+package people
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	samePrefLabel = "prefLabel"
+	aliasOverlap  = "aliases"
+	//minSharedAliases and minAliasOverlapPercent of the aliases of the person with the fewest make an alias overlap
+	minSharedAliases       = 2
+	minAliasOverlapPercent = 80
+	//maxAliasSharers leaves out the aliases so common they say nothing about who is who
+	maxAliasSharers = 20
+)
+
+//duplicateCluster is a group of people likely to be the same human under several TME ids
+type duplicateCluster struct {
+	UUIDs      []string `json:"uuids"`
+	PrefLabels []string `json:"prefLabels"`
+	Reasons    []string `json:"reasons"`
+	Canonical  string   `json:"canonical,omitempty"`
+}
+
+//duplicateClusters are the clusters found in a generation
+type duplicateClusters struct {
+	generation int
+	clusters   []duplicateCluster
+}
+
+//normaliseLabel ignores the case, punctuation and spacing of a name
+func normaliseLabel(label string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(label), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+//detectDuplicates clusters the people sharing a normalised prefLabel or most of their aliases, marking the canonical UUID of each cluster when one is given
+func detectDuplicates(people []person, canonical map[string]bool) []duplicateCluster {
+	type link struct {
+		from, to int
+		reason   string
+	}
+	var links []link
+
+	byLabel := map[string][]int{}
+	byAlias := map[string][]int{}
+	aliasCounts := make([]int, len(people))
+	for i, p := range people {
+		if label := normaliseLabel(p.PrefLabel); label != "" {
+			byLabel[label] = append(byLabel[label], i)
+		}
+		seen := map[string]bool{}
+		for _, alias := range p.Aliases {
+			if alias = normaliseLabel(alias); alias != "" && !seen[alias] {
+				seen[alias] = true
+				byAlias[alias] = append(byAlias[alias], i)
+			}
+		}
+		aliasCounts[i] = len(seen)
+	}
+	for _, sharers := range byLabel {
+		for _, i := range sharers[1:] {
+			links = append(links, link{sharers[0], i, samePrefLabel})
+		}
+	}
+	shared := map[[2]int]int{}
+	for _, sharers := range byAlias {
+		if len(sharers) > maxAliasSharers {
+			continue
+		}
+		for a := 0; a < len(sharers); a++ {
+			for b := a + 1; b < len(sharers); b++ {
+				shared[[2]int{sharers[a], sharers[b]}]++
+			}
+		}
+	}
+	for pair, count := range shared {
+		fewest := aliasCounts[pair[0]]
+		if aliasCounts[pair[1]] < fewest {
+			fewest = aliasCounts[pair[1]]
+		}
+		if count >= minSharedAliases && count*100 >= fewest*minAliasOverlapPercent {
+			links = append(links, link{pair[0], pair[1], aliasOverlap})
+		}
+	}
+
+	parents := make([]int, len(people))
+	for i := range parents {
+		parents[i] = i
+	}
+	find := func(i int) int {
+		for parents[i] != i {
+			parents[i] = parents[parents[i]]
+			i = parents[i]
+		}
+		return i
+	}
+	for _, l := range links {
+		parents[find(l.from)] = find(l.to)
+	}
+	reasons := map[int]map[string]bool{}
+	for _, l := range links {
+		root := find(l.from)
+		if reasons[root] == nil {
+			reasons[root] = map[string]bool{}
+		}
+		reasons[root][l.reason] = true
+	}
+
+	members := map[int][]int{}
+	for i := range people {
+		if root := find(i); reasons[root] != nil {
+			members[root] = append(members[root], i)
+		}
+	}
+	clusters := []duplicateCluster{}
+	for root, indexes := range members {
+		cluster := duplicateCluster{UUIDs: []string{}, PrefLabels: []string{}, Reasons: []string{}}
+		labels := map[string]bool{}
+		for _, i := range indexes {
+			p := people[i]
+			cluster.UUIDs = append(cluster.UUIDs, p.UUID)
+			if !labels[p.PrefLabel] {
+				labels[p.PrefLabel] = true
+				cluster.PrefLabels = append(cluster.PrefLabels, p.PrefLabel)
+			}
+			if cluster.Canonical == "" && canonical[p.UUID] {
+				cluster.Canonical = p.UUID
+			}
+		}
+		for reason := range reasons[root] {
+			cluster.Reasons = append(cluster.Reasons, reason)
+		}
+		sort.Strings(cluster.Reasons)
+		clusters = append(clusters, cluster)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].UUIDs[0] < clusters[j].UUIDs[0]
+	})
+	return clusters
+}
+
+//getDuplicates clusters the people served once per generation, the first time they are asked for, so loads are not held up by it
+func (s *peopleServiceImpl) getDuplicates() ([]duplicateCluster, error) {
+	s.RLock()
+	generation := s.dataset.generation
+	if s.duplicates != nil && s.duplicates.generation == generation {
+		defer s.RUnlock()
+		return s.duplicates.clusters, nil
+	}
+	people, err := s.servedPeople()
+	s.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	clusters := detectDuplicates(people, s.canonical)
+	s.Lock()
+	s.duplicates = &duplicateClusters{generation: generation, clusters: clusters}
+	s.Unlock()
+	return clusters, nil
+}
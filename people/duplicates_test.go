@@ -0,0 +1,102 @@
+package people
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormaliseLabel(t *testing.T) {
+	tests := []struct {
+		label      string
+		normalised string
+	}{
+		{"Bob Smith", "bob smith"},
+		{"  BOB   smith ", "bob smith"},
+		{"J.R. O'Brien-Smith", "j r o brien smith"},
+		{"Zoë Smith", "zoë smith"},
+		{"...", ""},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.normalised, normaliseLabel(test.label), test.label)
+	}
+}
+
+func TestDetectDuplicates(t *testing.T) {
+	tests := []struct {
+		name      string
+		people    []person
+		canonical map[string]bool
+		clusters  []duplicateCluster
+	}{
+		{"No duplicates",
+			[]person{{UUID: "1", PrefLabel: "Bob Smith", Aliases: []string{"Bob Smith"}}, {UUID: "2", PrefLabel: "Fred Bloggs", Aliases: []string{"Fred Bloggs"}}},
+			nil,
+			[]duplicateCluster{}},
+		{"Same normalised prefLabel",
+			[]person{{UUID: "1", PrefLabel: "Bob Smith"}, {UUID: "2", PrefLabel: "Fred Bloggs"}, {UUID: "3", PrefLabel: "bob  smith"}},
+			nil,
+			[]duplicateCluster{{UUIDs: []string{"1", "3"}, PrefLabels: []string{"Bob Smith", "bob  smith"}, Reasons: []string{samePrefLabel}}}},
+		{"Alias overlap",
+			[]person{
+				{UUID: "1", PrefLabel: "Robert Smith", Aliases: []string{"Robert Smith", "Bob Smith", "R. Smith"}},
+				{UUID: "2", PrefLabel: "Bob Smith Jr", Aliases: []string{"Bob Smith Jr", "Bob Smith", "Robert Smith", "R Smith"}}},
+			nil,
+			[]duplicateCluster{{UUIDs: []string{"1", "2"}, PrefLabels: []string{"Robert Smith", "Bob Smith Jr"}, Reasons: []string{aliasOverlap}}}},
+		{"A single shared alias is not enough",
+			[]person{{UUID: "1", PrefLabel: "Bob Smith", Aliases: []string{"Bob"}}, {UUID: "2", PrefLabel: "Bob Jones", Aliases: []string{"Bob"}}},
+			nil,
+			[]duplicateCluster{}},
+		{"Both reasons join a cluster, with its canonical UUID",
+			[]person{
+				{UUID: "1", PrefLabel: "Bob Smith", Aliases: []string{"Bob Smith", "Robert Smith"}},
+				{UUID: "2", PrefLabel: "Bob Smith"},
+				{UUID: "3", PrefLabel: "Robert Smith", Aliases: []string{"Robert Smith", "Bob Smith"}}},
+			map[string]bool{"2": true},
+			[]duplicateCluster{{UUIDs: []string{"1", "2", "3"}, PrefLabels: []string{"Bob Smith", "Robert Smith"}, Reasons: []string{aliasOverlap, samePrefLabel}, Canonical: "2"}}},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.clusters, detectDuplicates(test.people, test.canonical), test.name)
+	}
+}
+
+func TestLoadOverrides(t *testing.T) {
+	tmpfile := getTempFile(t)
+	defer os.Remove(tmpfile.Name())
+	assert.NoError(t, ioutil.WriteFile(tmpfile.Name(), []byte(`{"canonical":["`+testUUID+`"]}`), 0600))
+
+	overrides, err := LoadOverrides(tmpfile.Name())
+	assert.NoError(t, err)
+	assert.Equal(t, Overrides{Canonical: []string{testUUID}}, overrides)
+
+	_, err = LoadOverrides(tmpfile.Name() + ".missing")
+	assert.Error(t, err)
+}
+
+func TestDuplicatesAreDetectedAfterEachLoad(t *testing.T) {
+	tmpfile := getTempFile(t)
+	defer os.Remove(tmpfile.Name())
+	repo := dummyRepo{terms: []term{{CanonicalName: "Bob Smith", RawID: "bob"}, {CanonicalName: "Fred", RawID: "fred"}}}
+	listener := &recordingListener{}
+	canonical := transformPerson(term{RawID: "bob2"}, "taxonomy_string", LegacyUUIDScheme).UUID
+	service := NewPeopleService(&repo, "/base/url", "taxonomy_string", 1, tmpfile.Name(), withLoadListener(listener), WithOverrides(Overrides{Canonical: []string{canonical}}))
+	defer service.Shutdown()
+	assert.NoError(t, listener.waitForLoads(t, 1).err)
+
+	clusters, err := service.getDuplicates()
+	assert.NoError(t, err)
+	assert.Empty(t, clusters)
+
+	repo.terms = append(repo.terms, term{CanonicalName: "bob smith", RawID: "bob2"})
+	repo.count = 0
+	assert.NoError(t, service.reloadDB(context.Background()))
+	clusters, err = service.getDuplicates()
+	assert.NoError(t, err)
+	if assert.Len(t, clusters, 1) {
+		assert.Len(t, clusters[0].UUIDs, 2)
+		assert.Equal(t, canonical, clusters[0].Canonical)
+	}
+}
@@ -0,0 +1,96 @@
+package people
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+//datasetVersion identifies the people data served after a completed load
+type datasetVersion struct {
+	generation   int
+	etag         string
+	lastModified time.Time
+}
+
+//datasetDigest is an order independent digest of all the person hashes of a load, their sum modulo 2^256.
+//Unlike a xor, the same hash added twice does not cancel out
+type datasetDigest [sha256.Size]byte
+
+func (d *datasetDigest) add(sum [sha256.Size]byte) {
+	carry := 0
+	for i := len(d) - 1; i >= 0; i-- {
+		total := int(d[i]) + int(sum[i]) + carry
+		d[i] = byte(total)
+		carry = total >> 8
+	}
+}
+
+//addHex adds a person hash as stored in the hash bucket
+func (d *datasetDigest) addHex(hash []byte) error {
+	var sum [sha256.Size]byte
+	if _, err := hex.Decode(sum[:], hash); err != nil {
+		return err
+	}
+	d.add(sum)
+	return nil
+}
+
+func (d *datasetDigest) merge(other datasetDigest) {
+	d.add(other)
+}
+
+func (d datasetDigest) etag() string {
+	return quoteETag(hex.EncodeToString(d[:]))
+}
+
+func contentHash(marshalledPerson []byte) [sha256.Size]byte {
+	return sha256.Sum256(marshalledPerson)
+}
+
+func quoteETag(value string) string {
+	return "\"" + value + "\""
+}
+
+//writeNotModifiedIfUnchanged sets the validators on the response and writes a 304 if the request preconditions match them
+func writeNotModifiedIfUnchanged(writer http.ResponseWriter, req *http.Request, etag string, lastModified time.Time) bool {
+	if etag != "" {
+		writer.Header().Set("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		writer.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if req.Method != "GET" && req.Method != "HEAD" {
+		return false
+	}
+
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		if etag == "" || !etagMatches(inm, etag) {
+			return false
+		}
+	} else if ims := req.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ims)
+		if err != nil || lastModified.Truncate(time.Second).After(since) {
+			return false
+		}
+	} else {
+		return false
+	}
+
+	writer.Header().Del("Content-Type")
+	writer.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+//etagMatches does the weak comparison If-None-Match requires
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package people
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatasetDigestIsOrderIndependent(t *testing.T) {
+	var d1, d2 datasetDigest
+	d1.add(contentHash([]byte("bob")))
+	d1.add(contentHash([]byte("fred")))
+	d2.add(contentHash([]byte("fred")))
+	d2.add(contentHash([]byte("bob")))
+	assert.Equal(t, d1.etag(), d2.etag())
+
+	var d3 datasetDigest
+	d3.add(contentHash([]byte("bob")))
+	assert.NotEqual(t, d1.etag(), d3.etag())
+
+	var twice, none datasetDigest
+	twice.add(contentHash([]byte("bob")))
+	twice.add(contentHash([]byte("bob")))
+	assert.NotEqual(t, none.etag(), twice.etag(), "The same hash added twice does not cancel out")
+
+	var merged datasetDigest
+	merged.merge(d3)
+	merged.add(contentHash([]byte("fred")))
+	assert.Equal(t, d1.etag(), merged.etag())
+}
+
+func TestWriteNotModifiedIfUnchanged(t *testing.T) {
+	lastModified := time.Date(2017, time.May, 23, 10, 0, 0, 500, time.UTC)
+	tests := []struct {
+		name        string
+		method      string
+		header      string
+		value       string
+		notModified bool
+	}{
+		{"No preconditions", "GET", "", "", false},
+		{"Matching etag", "GET", "If-None-Match", `"abc"`, true},
+		{"Matching weak etag", "GET", "If-None-Match", `W/"abc"`, true},
+		{"Matching etag in list", "GET", "If-None-Match", `"xyz", "abc"`, true},
+		{"Wildcard etag", "GET", "If-None-Match", "*", true},
+		{"Different etag", "GET", "If-None-Match", `"xyz"`, false},
+		{"Not modified since", "GET", "If-Modified-Since", "Tue, 23 May 2017 10:00:00 GMT", true},
+		{"Modified since", "GET", "If-Modified-Since", "Tue, 23 May 2017 09:59:59 GMT", false},
+		{"Invalid date", "GET", "If-Modified-Since", "yesterday", false},
+		{"Not a GET", "POST", "If-None-Match", `"abc"`, false},
+	}
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		req, err := http.NewRequest(test.method, "/transformers/people", nil)
+		assert.NoError(t, err)
+		if test.header != "" {
+			req.Header.Set(test.header, test.value)
+		}
+		assert.Equal(t, test.notModified, writeNotModifiedIfUnchanged(rec, req, `"abc"`, lastModified), test.name)
+		assert.Equal(t, `"abc"`, rec.Header().Get("ETag"), test.name)
+		assert.Equal(t, "Tue, 23 May 2017 10:00:00 GMT", rec.Header().Get("Last-Modified"), test.name)
+		if test.notModified {
+			assert.Equal(t, http.StatusNotModified, rec.Code, test.name)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package people
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+//exportFormat is a bulk serialisation of the people dataset
+type exportFormat struct {
+	contentType string
+	filename    string
+	preamble    string
+	render      personRenderer
+}
+
+//exportFormats build each format from the query of the export request
+var exportFormats = map[string]func(query url.Values) (exportFormat, error){
+	"turtle": func(url.Values) (exportFormat, error) {
+		return exportFormat{contentType: "text/turtle; charset=utf-8", filename: "people.ttl", preamble: turtlePrefixes, render: renderTurtle}, nil
+	},
+	"ntriples": func(url.Values) (exportFormat, error) {
+		return exportFormat{contentType: "application/n-triples; charset=utf-8", filename: "people.nt", render: renderNTriples}, nil
+	},
+	"csv": newCSVExport,
+}
+
+func newExportFormat(query url.Values) (exportFormat, error) {
+	name := query.Get("format")
+	build, supported := exportFormats[name]
+	if !supported {
+		return exportFormat{}, fmt.Errorf("Unsupported export format [%v], expected one of: %v", name, supportedExportFormats())
+	}
+	return build(query)
+}
+
+func supportedExportFormats() string {
+	var names []string
+	for name := range exportFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
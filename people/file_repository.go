@@ -0,0 +1,117 @@
+package people
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/Financial-Times/tme-reader/tmereader"
+	log "github.com/Sirupsen/logrus"
+)
+
+//fileRepository serves the terms of TME taxonomy XML exports on disk, in pages of maxRecords like the TME API
+type fileRepository struct {
+	sync.RWMutex
+	path       string
+	maxRecords int
+	modeler    tmereader.Modeler
+	terms      []interface{}
+}
+
+//NewFileRepository reads people from a TME taxonomy XML export, or from a directory of exported pages read in file name order
+func NewFileRepository(path string, maxRecords int, modeler tmereader.Modeler) (tmereader.Repository, error) {
+	if maxRecords < 1 {
+		return nil, fmt.Errorf("Invalid page size %v", maxRecords)
+	}
+	r := &fileRepository{path: path, maxRecords: maxRecords, modeler: modeler}
+	if _, err := r.exportFiles(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+//GetTmeTermsFromIndex re-reads the export when asked for the first page, so a reload picks up a new export
+func (r *fileRepository) GetTmeTermsFromIndex(startRecord int) ([]interface{}, error) {
+	if startRecord == 0 {
+		if err := r.readTerms(); err != nil {
+			return nil, err
+		}
+	}
+	r.RLock()
+	defer r.RUnlock()
+	if startRecord >= len(r.terms) {
+		return []interface{}{}, nil
+	}
+	end := startRecord + r.maxRecords
+	if end > len(r.terms) {
+		end = len(r.terms)
+	}
+	return r.terms[startRecord:end], nil
+}
+
+func (r *fileRepository) GetTmeTermById(rawID string) (interface{}, error) {
+	r.RLock()
+	terms := r.terms
+	r.RUnlock()
+	if terms == nil {
+		if err := r.readTerms(); err != nil {
+			return nil, err
+		}
+		r.RLock()
+		terms = r.terms
+		r.RUnlock()
+	}
+	for _, t := range terms {
+		if tmeTerm, ok := t.(term); ok && tmeTerm.RawID == rawID {
+			return tmeTerm, nil
+		}
+	}
+	return nil, fmt.Errorf("Term %v not found in %v", rawID, r.path)
+}
+
+func (r *fileRepository) readTerms() error {
+	files, err := r.exportFiles()
+	if err != nil {
+		return err
+	}
+	terms := []interface{}{}
+	for _, file := range files {
+		contents, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		fileTerms, err := r.modeler.UnMarshallTaxonomy(contents)
+		if err != nil {
+			return fmt.Errorf("Error parsing %v: %v", file, err)
+		}
+		terms = append(terms, fileTerms...)
+	}
+	log.Infof("Read %v terms from %v file(s) in %v.", len(terms), len(files), r.path)
+
+	r.Lock()
+	r.terms = terms
+	r.Unlock()
+	return nil
+}
+
+func (r *fileRepository) exportFiles() ([]string, error) {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{r.path}, nil
+	}
+	files, err := filepath.Glob(filepath.Join(r.path, "*.xml"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("No xml files found in %v", r.path)
+	}
+	sort.Strings(files)
+	return files, nil
+}
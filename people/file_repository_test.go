@@ -0,0 +1,93 @@
+package people
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	firstTaxonomyPage  = `<taxonomy><term><name>Bob</name><id>bob</id><variations><variation><name>Bobby</name></variation></variations></term><term><name>Fred</name><id>fred</id></term></taxonomy>`
+	secondTaxonomyPage = `<taxonomy><term><name>Third</name><id>third</id></term></taxonomy>`
+)
+
+func TestFileRepositoryPages(t *testing.T) {
+	dir := writeTaxonomyPages(t, map[string]string{"page-2.xml": secondTaxonomyPage, "page-1.xml": firstTaxonomyPage, "notes.txt": "ignored"})
+	defer os.RemoveAll(dir)
+
+	repo, err := NewFileRepository(dir, 2, new(PersonTransformer))
+	assert.NoError(t, err)
+
+	terms, err := repo.GetTmeTermsFromIndex(0)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		term{CanonicalName: "Bob", RawID: "bob", Aliases: aliases{Alias: []alias{{Name: "Bobby"}}}},
+		term{CanonicalName: "Fred", RawID: "fred"},
+	}, terms)
+
+	terms, err = repo.GetTmeTermsFromIndex(2)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{term{CanonicalName: "Third", RawID: "third"}}, terms)
+
+	terms, err = repo.GetTmeTermsFromIndex(4)
+	assert.NoError(t, err)
+	assert.Empty(t, terms)
+
+	found, err := repo.GetTmeTermById("fred")
+	assert.NoError(t, err)
+	assert.Equal(t, term{CanonicalName: "Fred", RawID: "fred"}, found)
+	_, err = repo.GetTmeTermById("nobody")
+	assert.Error(t, err)
+}
+
+func TestFileRepositoryErrors(t *testing.T) {
+	dir := writeTaxonomyPages(t, map[string]string{"page-1.xml": "<taxonomy><term>"})
+	defer os.RemoveAll(dir)
+
+	_, err := NewFileRepository(filepath.Join(dir, "missing.xml"), 10, new(PersonTransformer))
+	assert.Error(t, err)
+
+	empty, err := ioutil.TempDir("", "file_repository_test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(empty)
+	_, err = NewFileRepository(empty, 10, new(PersonTransformer))
+	assert.Error(t, err)
+
+	repo, err := NewFileRepository(filepath.Join(dir, "page-1.xml"), 10, new(PersonTransformer))
+	assert.NoError(t, err)
+	_, err = repo.GetTmeTermsFromIndex(0)
+	assert.Error(t, err)
+}
+
+func TestLoadFromFileRepository(t *testing.T) {
+	dir := writeTaxonomyPages(t, map[string]string{"page-1.xml": firstTaxonomyPage})
+	defer os.RemoveAll(dir)
+	tmpfile := getTempFile(t)
+	defer os.Remove(tmpfile.Name())
+
+	repo, err := NewFileRepository(dir, 1, new(PersonTransformer))
+	assert.NoError(t, err)
+	service := createTestPeopleService(repo, tmpfile.Name())
+	defer service.Shutdown()
+	waitTillInit(t, service)
+	waitTillDataLoaded(t, service)
+	assertCount(t, service, 2)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "page-2.xml"), []byte(secondTaxonomyPage), 0600))
+	assert.NoError(t, service.reloadDB(context.Background()))
+	waitTillDataLoaded(t, service)
+	assertCount(t, service, 3)
+}
+
+func writeTaxonomyPages(t *testing.T, pages map[string]string) string {
+	dir, err := ioutil.TempDir("", "file_repository_test")
+	assert.NoError(t, err)
+	for name, contents := range pages {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0600))
+	}
+	return dir
+}
@@ -0,0 +1,74 @@
+package people
+
+import (
+	"strconv"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/boltdb/bolt"
+)
+
+const generationBucket = "generation"
+
+var currentGenerationKey = []byte("current")
+
+//cacheGeneration names the buckets holding the people of one load
+type cacheGeneration struct {
+	people     string
+	hashes     string
+	quarantine string
+	aliases    string
+}
+
+//cacheGenerations are written to in turn, so the people of the previous load are kept until the next one
+var cacheGenerations = [2]cacheGeneration{
+	{cacheBucket, hashBucket, quarantineBucket, aliasBucket},
+	{cacheBucket + "Alt", hashBucket + "Alt", quarantineBucket + "Alt", aliasBucket + "Alt"},
+}
+
+//currentGeneration is the index of the generation served, the first one in a cache file written before generations
+func currentGeneration(tx *bolt.Tx) int {
+	if bucket := tx.Bucket([]byte(generationBucket)); bucket != nil && string(bucket.Get(currentGenerationKey)) == "1" {
+		return 1
+	}
+	return 0
+}
+
+func setCurrentGeneration(tx *bolt.Tx, i int) error {
+	bucket, err := tx.CreateBucketIfNotExists([]byte(generationBucket))
+	if err != nil {
+		return err
+	}
+	return bucket.Put(currentGenerationKey, []byte(strconv.Itoa(i)))
+}
+
+//index is the position of the generation in cacheGenerations
+func (g cacheGeneration) index() int {
+	if g == cacheGenerations[1] {
+		return 1
+	}
+	return 0
+}
+
+func otherGeneration(i int) int {
+	return 1 - i
+}
+
+//recreate empties the buckets of the generation, returning the people and hashes ones
+func (g cacheGeneration) recreate(tx *bolt.Tx) (*bolt.Bucket, *bolt.Bucket, error) {
+	var buckets [4]*bolt.Bucket
+	for i, name := range []string{g.people, g.hashes, g.quarantine, g.aliases} {
+		if tx.Bucket([]byte(name)) != nil {
+			log.Infof("Deleting bucket '%v'.", name)
+			if err := tx.DeleteBucket([]byte(name)); err != nil {
+				log.Warnf("Cache bucket [%v] could not be deleted.", name)
+			}
+		}
+		log.Infof("Creating bucket '%s'.", name)
+		bucket, err := tx.CreateBucket([]byte(name))
+		if err != nil {
+			return nil, nil, err
+		}
+		buckets[i] = bucket
+	}
+	return buckets[0], buckets[1], nil
+}
@@ -0,0 +1,64 @@
+package people
+
+import (
+	"fmt"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/boltdb/bolt"
+)
+
+//WithShrinkGuard rejects a load with more than maxShrinkPercent fewer people than the ones served, which are kept
+func WithShrinkGuard(maxShrinkPercent int) ServiceOption {
+	return func(s *peopleServiceImpl) {
+		s.maxShrink = maxShrinkPercent
+	}
+}
+
+//guardShrink compares the people loaded into the generation with the ones served
+func (s *peopleServiceImpl) guardShrink(into cacheGeneration) error {
+	s.RLock()
+	defer s.RUnlock()
+	if s.maxShrink <= 0 {
+		return nil
+	}
+	var served, loaded int
+	if err := s.db.View(func(tx *bolt.Tx) error {
+		if bucket := tx.Bucket([]byte(s.served().people)); bucket != nil {
+			served = bucket.Stats().KeyN
+		}
+		if bucket := tx.Bucket([]byte(into.people)); bucket != nil {
+			loaded = bucket.Stats().KeyN
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if served > 0 && (served-loaded)*100 > served*s.maxShrink {
+		return fmt.Errorf("Load rejected, its %v people are more than %v%% fewer than the %v people served", loaded, s.maxShrink, served)
+	}
+	return nil
+}
+
+//rejectLoad keeps serving the people of the previous load, emptying the generation rejected which is not diffed against
+func (s *peopleServiceImpl) rejectLoad(reason error) {
+	s.Lock()
+	defer s.Unlock()
+	s.loading = false
+	s.discarded = true
+	s.rejectedLoad = reason
+	s.dataLoaded = true
+	rejected := cacheGenerations[otherGeneration(s.current)]
+	if err := s.db.Update(func(tx *bolt.Tx) error {
+		_, _, err := rejected.recreate(tx)
+		return err
+	}); err != nil {
+		log.Errorf("ERROR emptying the rejected load: %v", err.Error())
+	}
+}
+
+//getRejectedLoad tells why the last load was rejected, nil once a load is served
+func (s *peopleServiceImpl) getRejectedLoad() error {
+	s.RLock()
+	defer s.RUnlock()
+	return s.rejectedLoad
+}
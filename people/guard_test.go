@@ -0,0 +1,54 @@
+package people
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShrinkGuard(t *testing.T) {
+	tmpfile := getTempFile(t)
+	defer os.Remove(tmpfile.Name())
+	repo := dummyRepo{terms: []term{{CanonicalName: "Bob", RawID: "bob"}, {CanonicalName: "Fred", RawID: "fred"}, {CanonicalName: "Third", RawID: "third"}, {CanonicalName: "Fourth", RawID: "fourth"}}}
+	listener := &recordingListener{}
+	service := NewPeopleService(&repo, "/base/url", "taxonomy_string", 1, tmpfile.Name(), WithShrinkGuard(25), withLoadListener(listener))
+	defer service.Shutdown()
+	waitTillInit(t, service)
+	listener.waitForLoads(t, 1)
+	assertCount(t, service, 4)
+	served := service.getDatasetVersion()
+
+	repo.terms = repo.terms[:3]
+	repo.count = 0
+	assert.NoError(t, service.reloadDB(context.Background()), "Losing 25% of the people is allowed")
+	assertCount(t, service, 3)
+	assert.NoError(t, service.getRejectedLoad())
+
+	repo.terms = repo.terms[:1]
+	repo.count = 0
+	err := service.reloadDB(context.Background())
+	assert.EqualError(t, err, "Load rejected, its 1 people are more than 25% fewer than the 3 people served")
+	assert.Equal(t, err, service.getRejectedLoad())
+	assert.Equal(t, err, listener.waitForLoads(t, 3).err)
+	assert.True(t, service.isDataLoaded())
+	assertCount(t, service, 3)
+	_, found, _ := service.getPersonByUUID(fredUUID)
+	assert.True(t, found, "The people served before the rejected load are kept")
+	assert.Equal(t, served.generation+1, service.getDatasetVersion().generation)
+	_, available, err := service.getDatasetDiff()
+	assert.NoError(t, err)
+	assert.False(t, available, "The rejected load is not diffed against")
+
+	repo.terms = []term{{CanonicalName: "Bob", RawID: "bob"}, {CanonicalName: "Fred", RawID: "fred"}, {CanonicalName: "Third", RawID: "third"}}
+	repo.count = 0
+	assert.NoError(t, service.reloadDB(context.Background()))
+	assert.NoError(t, service.getRejectedLoad())
+	assertCount(t, service, 3)
+	diff, available, err := service.getDatasetDiff()
+	assert.NoError(t, err)
+	assert.True(t, available)
+	assert.Equal(t, 3, diff.PreviousCount)
+	assert.Empty(t, diff.Added)
+}
@@ -7,9 +7,12 @@ import (
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Financial-Times/go-fthealth/v1a"
 	"github.com/Financial-Times/service-status-go/gtg"
+	"github.com/Financial-Times/transactionid-utils-go"
 	log "github.com/Sirupsen/logrus"
 	"github.com/gorilla/mux"
 )
@@ -23,7 +26,7 @@ func NewPeopleHandler(service PeopleService) PeopleHandler {
 }
 
 func (h *PeopleHandler) GetPeople(writer http.ResponseWriter, req *http.Request) {
-	writer.Header().Add("Content-Type", "application/json")
+	repr := negotiateRepresentation(writer, req)
 	if !h.service.isInitialised() {
 		writeStatusServiceUnavailable(writer)
 		return
@@ -34,15 +37,19 @@ func (h *PeopleHandler) GetPeople(writer http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	pv, err := h.service.getPeople()
+	encoding := negotiateEncoding(writer, req)
+	if h.datasetNotModified(writer, req, repr.variant, encoding) {
+		return
+	}
+
+	pv, err := h.getPeopleIn(repr)
 
 	if err != nil {
 		writeJSONMessageWithStatus(writer, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	defer pv.Close()
-	writer.WriteHeader(http.StatusOK)
-	io.Copy(writer, &pv)
+	writeStream(writer, &pv, encoding)
 }
 
 func (h *PeopleHandler) GetPeopleUUIDs(writer http.ResponseWriter, req *http.Request) {
@@ -57,6 +64,11 @@ func (h *PeopleHandler) GetPeopleUUIDs(writer http.ResponseWriter, req *http.Req
 		return
 	}
 
+	encoding := negotiateEncoding(writer, req)
+	if h.datasetNotModified(writer, req, encoding) {
+		return
+	}
+
 	pv, err := h.service.getPeopleUUIDs()
 
 	if err != nil {
@@ -64,8 +76,176 @@ func (h *PeopleHandler) GetPeopleUUIDs(writer http.ResponseWriter, req *http.Req
 		return
 	}
 	defer pv.Close()
-	writer.WriteHeader(http.StatusOK)
-	io.Copy(writer, &pv)
+	writeStream(writer, &pv, encoding)
+}
+
+func (h *PeopleHandler) GetPeopleLinks(writer http.ResponseWriter, req *http.Request) {
+	writer.Header().Add("Content-Type", "application/json")
+	if !h.service.isInitialised() {
+		writeStatusServiceUnavailable(writer)
+		return
+	}
+
+	if c, _ := h.service.getCount(); c == 0 {
+		writeJSONMessageWithStatus(writer, "People not found", http.StatusNotFound)
+		return
+	}
+
+	encoding := negotiateEncoding(writer, req)
+	if h.datasetNotModified(writer, req, encoding) {
+		return
+	}
+
+	pv, err := h.service.getPeopleLinks(forwardedHeadersFromRequest(req))
+
+	if err != nil {
+		writeJSONMessageWithStatus(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer pv.Close()
+	writeStream(writer, &pv, encoding)
+}
+
+func (h *PeopleHandler) Export(writer http.ResponseWriter, req *http.Request) {
+	writer.Header().Add("Content-Type", "application/json")
+	if !h.service.isInitialised() {
+		writeStatusServiceUnavailable(writer)
+		return
+	}
+
+	format, err := newExportFormat(req.URL.Query())
+	if err != nil {
+		writeJSONMessageWithStatus(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if c, _ := h.service.getCount(); c == 0 {
+		writeJSONMessageWithStatus(writer, "People not found", http.StatusNotFound)
+		return
+	}
+
+	encoding := negotiateEncoding(writer, req)
+	if h.datasetNotModified(writer, req, encoding) {
+		return
+	}
+
+	pv, err := h.service.getPeopleAs(format.render)
+
+	if err != nil {
+		writeJSONMessageWithStatus(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer pv.Close()
+	writer.Header().Set("Content-Type", format.contentType)
+	writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", format.filename))
+	writeStream(writer, io.MultiReader(strings.NewReader(format.preamble), &pv), encoding)
+}
+
+func (h *PeopleHandler) GetSnapshot(writer http.ResponseWriter, req *http.Request) {
+	if !h.service.isInitialised() || !h.service.isDataLoaded() {
+		writer.Header().Add("Content-Type", "application/json")
+		writeStatusServiceUnavailable(writer)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/gzip")
+	writer.Header().Set("Content-Disposition", "attachment; filename=\"people-snapshot.ndjson.gz\"")
+	if err := h.service.exportSnapshot(writer); err != nil {
+		log.Errorf("ERROR exporting snapshot: %v", err.Error())
+	}
+}
+
+func (h *PeopleHandler) PutSnapshot(writer http.ResponseWriter, req *http.Request) {
+	writer.Header().Add("Content-Type", "application/json")
+	if !h.service.isInitialised() {
+		writeStatusServiceUnavailable(writer)
+		return
+	}
+	count, err := h.service.importSnapshot(req.Body)
+	if err == errAlreadyLoading {
+		writeJSONMessageWithStatus(writer, "People are loading, retry the import once they are loaded", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		writeJSONMessageWithStatus(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSONMessageWithStatus(writer, fmt.Sprintf("Imported %d people", count), http.StatusOK)
+}
+
+//GetErrors lists the TME terms the load served left out, with the reason why
+func (h *PeopleHandler) GetErrors(writer http.ResponseWriter, req *http.Request) {
+	writer.Header().Add("Content-Type", "application/json")
+	if !h.service.isInitialised() || !h.service.isDataLoaded() {
+		writeStatusServiceUnavailable(writer)
+		return
+	}
+
+	quarantine, err := h.service.getQuarantine()
+	if err != nil {
+		writeJSONMessageWithStatus(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := json.NewEncoder(writer).Encode(quarantine); err != nil {
+		writeJSONMessageWithStatus(writer, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+//GetQuality reports on the quality of the people served
+func (h *PeopleHandler) GetQuality(writer http.ResponseWriter, req *http.Request) {
+	writer.Header().Add("Content-Type", "application/json")
+	if !h.service.isInitialised() || !h.service.isDataLoaded() {
+		writeStatusServiceUnavailable(writer)
+		return
+	}
+
+	report, err := h.service.getQualityReport()
+	if err != nil {
+		writeJSONMessageWithStatus(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := json.NewEncoder(writer).Encode(report); err != nil {
+		writeJSONMessageWithStatus(writer, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+//GetDuplicates lists the clusters of people likely to be the same human under several TME ids
+func (h *PeopleHandler) GetDuplicates(writer http.ResponseWriter, req *http.Request) {
+	writer.Header().Add("Content-Type", "application/json")
+	if !h.service.isInitialised() || !h.service.isDataLoaded() {
+		writeStatusServiceUnavailable(writer)
+		return
+	}
+
+	clusters, err := h.service.getDuplicates()
+	if err != nil {
+		writeJSONMessageWithStatus(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := json.NewEncoder(writer).Encode(clusters); err != nil {
+		writeJSONMessageWithStatus(writer, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (h *PeopleHandler) GetDiff(writer http.ResponseWriter, req *http.Request) {
+	writer.Header().Add("Content-Type", "application/json")
+	if !h.service.isInitialised() || !h.service.isDataLoaded() {
+		writeStatusServiceUnavailable(writer)
+		return
+	}
+
+	diff, available, err := h.service.getDatasetDiff()
+	if err != nil {
+		writeJSONMessageWithStatus(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !available {
+		writeJSONMessageWithStatus(writer, "No previous load to diff against, people are loading or the last load was not served", http.StatusServiceUnavailable)
+		return
+	}
+	if err := json.NewEncoder(writer).Encode(diff); err != nil {
+		writeJSONMessageWithStatus(writer, err.Error(), http.StatusInternalServerError)
+	}
 }
 
 func (h *PeopleHandler) GetCount(writer http.ResponseWriter, req *http.Request) {
@@ -100,16 +280,102 @@ func (h *PeopleHandler) HealthCheck() v1a.Check {
 	}
 }
 
+func (h *PeopleHandler) LoadCheck() v1a.Check {
+
+	return v1a.Check{
+		BusinessImpact:   "People served are not up to date with TME",
+		Name:             "Check the last load of people was not rejected.",
+		PanicGuide:       "https://sites.google.com/a/ft.com/ft-technology-service-transition/home/run-book-library/v1-people-transformer",
+		Severity:         2,
+		TechnicalSummary: "The last load had far fewer people than the ones served, which were kept. Check TME returns all the people then reload.",
+		Checker: func() (string, error) {
+			if err := h.service.getRejectedLoad(); err != nil {
+				return "Last load rejected", err
+			}
+			return "Last load was served", nil
+		},
+	}
+}
+
+func (h *PeopleHandler) TMECheck() v1a.Check {
+
+	return v1a.Check{
+		BusinessImpact:   "People cannot be reloaded, the ones served will get out of date",
+		Name:             "Check TME is reachable and accepts the credentials.",
+		PanicGuide:       "https://sites.google.com/a/ft.com/ft-technology-service-transition/home/run-book-library/v1-people-transformer",
+		Severity:         2,
+		TechnicalSummary: "Fetching a single TME term failed. Check TME is up and the TME username, password and token are valid.",
+		Checker: func() (string, error) {
+			if err := h.service.checkRepository(); err != nil {
+				return "TME probe failed", err
+			}
+			return "TME is reachable", nil
+		},
+	}
+}
+
+func (h *PeopleHandler) DataFreshnessCheck() v1a.Check {
+
+	return v1a.Check{
+		BusinessImpact:   "People served may be out of date",
+		Name:             "Check people were loaded from TME recently.",
+		PanicGuide:       "https://sites.google.com/a/ft.com/ft-technology-service-transition/home/run-book-library/v1-people-transformer",
+		Severity:         2,
+		TechnicalSummary: "No load from TME was served recently. Check the reloads are requested and succeed.",
+		Checker: func() (string, error) {
+			return h.service.checkDataFreshness()
+		},
+	}
+}
+
+func (h *PeopleHandler) CacheDBCheck() v1a.Check {
+
+	return v1a.Check{
+		BusinessImpact:   "Unable to respond to requests",
+		Name:             "Check the cache file can be read.",
+		PanicGuide:       "https://sites.google.com/a/ft.com/ft-technology-service-transition/home/run-book-library/v1-people-transformer",
+		Severity:         1,
+		TechnicalSummary: "The people cannot be read from the cache file. Check the disk, then restart the service.",
+		Checker: func() (string, error) {
+			if err := h.service.checkCacheDB(); err != nil {
+				return "Cache file unreadable", err
+			}
+			return "Cache file is readable", nil
+		},
+	}
+}
+
+//HealthChecks are all the checks of /__health
+func (h *PeopleHandler) HealthChecks() []v1a.Check {
+	return []v1a.Check{h.HealthCheck(), h.LoadCheck(), h.TMECheck(), h.DataFreshnessCheck(), h.CacheDBCheck()}
+}
+
+//G2GCheck stays good to go while a reload replaces the people served, only failing when none can be served
 func (h *PeopleHandler) G2GCheck() gtg.Status {
+	if !h.service.isInitialised() {
+		return gtg.Status{GoodToGo: false, Message: "Service is not initialised, the cache file could not be opened"}
+	}
+	if !h.service.isDataLoaded() {
+		if h.service.isLoading() {
+			return gtg.Status{GoodToGo: false, Message: "People are loading for the first time"}
+		}
+		return gtg.Status{GoodToGo: false, Message: "No people loaded"}
+	}
 	count, err := h.service.getCount()
-	if h.service.isInitialised() && err == nil && count > 0 {
-		return gtg.Status{GoodToGo: true}
+	if err != nil {
+		return gtg.Status{GoodToGo: false, Message: fmt.Sprintf("People cannot be read from the cache file: %v", err)}
 	}
-	return gtg.Status{GoodToGo: false}
+	if count == 0 {
+		return gtg.Status{GoodToGo: false, Message: "No people served, the last load was empty"}
+	}
+	if err := h.service.checkDataAge(); err != nil {
+		return gtg.Status{GoodToGo: false, Message: err.Error()}
+	}
+	return gtg.Status{GoodToGo: true}
 }
 
 func (h *PeopleHandler) GetPersonByUUID(writer http.ResponseWriter, req *http.Request) {
-	writer.Header().Add("Content-Type", "application/json")
+	repr := negotiateRepresentation(writer, req)
 	if !h.service.isInitialised() {
 		writeStatusServiceUnavailable(writer)
 		return
@@ -121,21 +387,42 @@ func (h *PeopleHandler) GetPersonByUUID(writer http.ResponseWriter, req *http.Re
 	obj, found, err := h.service.getPersonByUUID(uuid)
 	if err != nil {
 		writeJSONMessageWithStatus(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if found && obj.Hash != "" && writeNotModifiedIfUnchanged(writer, req, variantETag(quoteETag(obj.Hash), repr.variant), time.Time{}) {
+		return
+	}
+	writeJSONResponse(repr.of(obj), found, writer)
+}
+
+func (h *PeopleHandler) getPeopleIn(repr representation) (io.PipeReader, error) {
+	if repr.isDefault() {
+		return h.service.getPeople()
 	}
-	writeJSONResponse(obj, found, writer)
+	return h.service.getPeopleAs(repr.render)
+}
+
+//datasetNotModified answers list requests from the validators of the last completed load
+func (h *PeopleHandler) datasetNotModified(writer http.ResponseWriter, req *http.Request, variants ...string) bool {
+	if !h.service.isDataLoaded() {
+		return false
+	}
+	version := h.service.getDatasetVersion()
+	return writeNotModifiedIfUnchanged(writer, req, variantETag(version.etag, variants...), version.lastModified)
 }
 
 func (h *PeopleHandler) Reload(writer http.ResponseWriter, req *http.Request) {
-	if !h.service.isInitialised() || !h.service.isDataLoaded() {
+	if !h.service.isInitialised() {
 		writeStatusServiceUnavailable(writer)
 		return
 	}
-
-	go func() {
-		if err := h.service.reloadDB(); err != nil {
-			log.Errorf("ERROR opening db: %v", err.Error())
-		}
-	}()
+	ctx := detach(req.Context())
+	if !h.service.startReload(ctx) {
+		writeJSONMessageWithStatus(writer, "People are already loading", http.StatusServiceUnavailable)
+		return
+	}
+	transactionID, _ := transactionidutils.GetTransactionIDFromContext(ctx)
+	event(log.WithField("transaction_id", transactionID), "reload_requested").Info("Reloading people.")
 	writeJSONMessageWithStatus(writer, "Reloading people", http.StatusAccepted)
 }
 
@@ -168,6 +455,12 @@ func (h *PeopleHandler) OnlyPostAllowed(writer http.ResponseWriter, req *http.Re
 	writer.WriteHeader(http.StatusMethodNotAllowed)
 }
 
+//OnlyGetOrPutAllowed - Used to tell the user the METHOD type is not GET or PUT.
+func (h *PeopleHandler) OnlyGetOrPutAllowed(writer http.ResponseWriter, req *http.Request) {
+	writer.Header().Set("Allow", "GET, PUT")
+	writer.WriteHeader(http.StatusMethodNotAllowed)
+}
+
 //OnlyGetAllowed - Used to tell the user the METHOD type is not GET.
 func (h *PeopleHandler) OnlyGetAllowed(writer http.ResponseWriter, req *http.Request) {
 	writer.Header().Set("Allow", "GET")
@@ -1,6 +1,7 @@
 package people
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -12,6 +13,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/Financial-Times/go-fthealth/v1a"
 	"github.com/Financial-Times/service-status-go/gtg"
@@ -30,6 +32,7 @@ const (
 	getPeopleByUUIDResponse = `{"ID":"bba39990-c78d-3629-ae83-808c333c6dbc"}
 {"ID":"be2e7e2b-0fa2-3969-a69b-74c46e754032"}
 `
+	getPeopleLinksResponse  = `[{"apiUrl":"http://localhost:8080/transformers/people/bba39990-c78d-3629-ae83-808c333c6dbc"},{"apiUrl":"http://localhost:8080/transformers/people/be2e7e2b-0fa2-3969-a69b-74c46e754032"}]`
 	getPersonByUUIDResponse = "{\"uuid\":\"bba39990-c78d-3629-ae83-808c333c6dbc\",\"prefLabel\":\"European Union\",\"type\":\"Organisation\",\"alternativeIdentifiers\":{\"TME\":[\"MTE3-U3ViamVjdHM=\"],\"uuids\":[\"bba39990-c78d-3629-ae83-808c333c6dbc\"]}}\n"
 )
 
@@ -196,6 +199,43 @@ func TestHandlers(t *testing.T) {
 			http.StatusServiceUnavailable,
 			"application/json",
 			"{\"message\": \"Service Unavailable\"}\n"},
+		{"get people links - Success",
+			newRequest("GET", "/transformers/people/__links"),
+			&dummyService{
+				found:       true,
+				initialised: true,
+				count:       2,
+				people:      []person{{UUID: testUUID}, {UUID: testUUID2}}},
+			http.StatusOK,
+			"application/json",
+			getPeopleLinksResponse},
+		{"get people links - 405",
+			newRequest("POST", "/transformers/people/__links"),
+			&dummyService{
+				found:       true,
+				initialised: true,
+				count:       2,
+				people:      []person{{UUID: testUUID}, {UUID: testUUID2}}},
+			http.StatusMethodNotAllowed,
+			"application/json",
+			""},
+		{"get people links - Not found",
+			newRequest("GET", "/transformers/people/__links"),
+			&dummyService{
+				initialised: true,
+				count:       0,
+				people:      []person{}},
+			http.StatusNotFound,
+			"application/json",
+			"{\"message\": \"People not found\"}\n"},
+		{"get people links - Service unavailable",
+			newRequest("GET", "/transformers/people/__links"),
+			&dummyService{
+				initialised: false,
+				people:      []person{}},
+			http.StatusServiceUnavailable,
+			"application/json",
+			"{\"message\": \"Service Unavailable\"}\n"},
 		{"GTG unavailable - get GTG",
 			newRequest("GET", status.GTGPath),
 			&dummyService{
@@ -204,7 +244,7 @@ func TestHandlers(t *testing.T) {
 				people:      []person{}},
 			http.StatusServiceUnavailable,
 			"application/json",
-			""},
+			"Service is not initialised, the cache file could not be opened"},
 		{"GTG unavailable - get GTG but no people",
 			newRequest("GET", status.GTGPath),
 			&dummyService{
@@ -212,21 +252,59 @@ func TestHandlers(t *testing.T) {
 				initialised: true},
 			http.StatusServiceUnavailable,
 			"application/json",
-			""},
+			"No people loaded"},
+		{"GTG unavailable - get GTG while loading the first time",
+			newRequest("GET", status.GTGPath),
+			&dummyService{
+				initialised: true,
+				loading:     true},
+			http.StatusServiceUnavailable,
+			"application/json",
+			"People are loading for the first time"},
 		{"GTG unavailable - get GTG count returns error",
 			newRequest("GET", status.GTGPath),
 			&dummyService{
 				found:       false,
 				initialised: true,
+				dataLoaded:  true,
 				err:         errors.New("Count error")},
 			http.StatusServiceUnavailable,
 			"application/json",
-			""},
+			"People cannot be read from the cache file: Count error"},
+		{"GTG unavailable - get GTG with an empty load",
+			newRequest("GET", status.GTGPath),
+			&dummyService{
+				initialised: true,
+				dataLoaded:  true},
+			http.StatusServiceUnavailable,
+			"application/json",
+			"No people served, the last load was empty"},
+		{"GTG unavailable - get GTG with stale people",
+			newRequest("GET", status.GTGPath),
+			&dummyService{
+				initialised: true,
+				dataLoaded:  true,
+				count:       2,
+				stale:       errors.New("People were last loaded from TME 49h0m0s ago, more than 48h0m0s")},
+			http.StatusServiceUnavailable,
+			"application/json",
+			"People were last loaded from TME 49h0m0s ago, more than 48h0m0s"},
 		{"GTG OK - get GTG",
 			newRequest("GET", status.GTGPath),
 			&dummyService{
 				found:       true,
 				initialised: true,
+				dataLoaded:  true,
+				count:       2},
+			http.StatusOK,
+			"application/json",
+			"OK"},
+		{"GTG OK - get GTG while reloading",
+			newRequest("GET", status.GTGPath),
+			&dummyService{
+				initialised: true,
+				dataLoaded:  true,
+				loading:     true,
 				count:       2},
 			http.StatusOK,
 			"application/json",
@@ -247,6 +325,38 @@ func TestHandlers(t *testing.T) {
 			http.StatusOK,
 			"application/json",
 			"regex=Service is up and running"},
+		{"Health warning - last load rejected",
+			newRequest("GET", "/__health"),
+			&dummyService{
+				initialised:  true,
+				rejectedLoad: errors.New("Load rejected, its 1 people are more than 50% fewer than the 10 people served")},
+			http.StatusOK,
+			"application/json",
+			"regex=Load rejected, its 1 people"},
+		{"Health warning - TME credentials rejected",
+			newRequest("GET", "/__health"),
+			&dummyService{
+				initialised: true,
+				probeErr:    errors.New("TME rejected the credentials with status 401")},
+			http.StatusOK,
+			"application/json",
+			"regex=TME rejected the credentials with status 401"},
+		{"Health warning - stale data",
+			newRequest("GET", "/__health"),
+			&dummyService{
+				initialised: true,
+				stale:       errors.New("People were last loaded from TME 49h0m0s ago, more than 48h0m0s")},
+			http.StatusOK,
+			"application/json",
+			"regex=People were last loaded from TME 49h0m0s ago, more than 48h0m0s"},
+		{"Health bad - cache file unreadable",
+			newRequest("GET", "/__health"),
+			&dummyService{
+				initialised: true,
+				dbErr:       errors.New("database not open")},
+			http.StatusOK,
+			"application/json",
+			"regex=database not open"},
 		{"Reload accepted - request reload",
 			newRequest("POST", "/transformers/people/__reload"),
 			&dummyService{
@@ -285,16 +395,232 @@ func TestHandlers(t *testing.T) {
 			http.StatusServiceUnavailable,
 			"application/json",
 			"{\"message\": \"Service Unavailable\"}\n"},
-		{"Reload - Service unavailable as data not loaded",
+		{"Reload accepted even though the data failed to load",
 			newRequest("POST", "/transformers/people/__reload"),
 			&dummyService{
 				wg:          &wg,
 				err:         errors.New("Boom goes the backend..."),
 				initialised: true,
 				dataLoaded:  false},
+			http.StatusAccepted,
+			"application/json",
+			"{\"message\": \"Reloading people\"}\n"},
+		{"Reload - Service unavailable as already loading",
+			newRequest("POST", "/transformers/people/__reload"),
+			&dummyService{
+				wg:          &wg,
+				initialised: true,
+				dataLoaded:  true,
+				loading:     true},
+			http.StatusServiceUnavailable,
+			"application/json",
+			"{\"message\": \"People are already loading\"}\n"},
+		{"Errors - Success",
+			newRequest("GET", "/transformers/people/__errors"),
+			&dummyService{
+				initialised: true,
+				dataLoaded:  true,
+				quarantine:  []quarantinedTerm{{ID: "bob", Reason: "Term has no name", Term: term{RawID: "bob"}}}},
+			http.StatusOK,
+			"application/json",
+			`[{"id":"bob","reason":"Term has no name","term":{"CanonicalName":"","RawID":"bob","Aliases":{"Alias":null}}}]` + "\n"},
+		{"Errors - None",
+			newRequest("GET", "/transformers/people/__errors"),
+			&dummyService{
+				initialised: true,
+				dataLoaded:  true,
+				quarantine:  []quarantinedTerm{}},
+			http.StatusOK,
+			"application/json",
+			"[]\n"},
+		{"Errors - Service unavailable as data not loaded",
+			newRequest("GET", "/transformers/people/__errors"),
+			&dummyService{
+				initialised: true,
+				dataLoaded:  false},
+			http.StatusServiceUnavailable,
+			"application/json",
+			"{\"message\": \"Service Unavailable\"}\n"},
+		{"405 - errors",
+			newRequest("POST", "/transformers/people/__errors"),
+			&dummyService{
+				initialised: true,
+				dataLoaded:  true},
+			http.StatusMethodNotAllowed,
+			"application/json",
+			""},
+		{"Quality - Success",
+			newRequest("GET", "/transformers/people/__quality"),
+			&dummyService{
+				initialised: true,
+				dataLoaded:  true,
+				quality:     buildQualityReport([]person{{UUID: testUUID, PrefLabel: "Bob", Aliases: []string{"Bob"}}})},
+			http.StatusOK,
+			"application/json",
+			`{"count":1,"missingFields":{"aliases":0,"alternativeIdentifiers.TME":1,"name":1,"prefLabel":0},"duplicatePrefLabels":[],` +
+				`"aliasCounts":{"0":0,"1":1,"11-50":0,"2-5":0,"51+":0,"6-10":0},"mostAliases":[{"uuid":"` + testUUID + `","prefLabel":"Bob","aliases":1}],"nonLatinNames":[],"suspiciousCharacters":[]}` + "\n"},
+		{"Quality - Cache file unreadable",
+			newRequest("GET", "/transformers/people/__quality"),
+			&dummyService{
+				initialised: true,
+				dataLoaded:  true,
+				err:         errors.New("Cache bucket [person] not found!")},
+			http.StatusInternalServerError,
+			"application/json",
+			"{\"message\": \"Cache bucket [person] not found!\"}\n"},
+		{"Quality - Service unavailable as data not loaded",
+			newRequest("GET", "/transformers/people/__quality"),
+			&dummyService{
+				initialised: true,
+				dataLoaded:  false},
+			http.StatusServiceUnavailable,
+			"application/json",
+			"{\"message\": \"Service Unavailable\"}\n"},
+		{"405 - quality",
+			newRequest("POST", "/transformers/people/__quality"),
+			&dummyService{
+				initialised: true,
+				dataLoaded:  true},
+			http.StatusMethodNotAllowed,
+			"application/json",
+			""},
+		{"Duplicates - Success",
+			newRequest("GET", "/transformers/people/__duplicates"),
+			&dummyService{
+				initialised: true,
+				dataLoaded:  true,
+				duplicates:  []duplicateCluster{{UUIDs: []string{testUUID, testUUID2}, PrefLabels: []string{"Bob Smith", "bob smith"}, Reasons: []string{samePrefLabel}, Canonical: testUUID}}},
+			http.StatusOK,
+			"application/json",
+			`[{"uuids":["` + testUUID + `","` + testUUID2 + `"],"prefLabels":["Bob Smith","bob smith"],"reasons":["prefLabel"],"canonical":"` + testUUID + `"}]` + "\n"},
+		{"Duplicates - None",
+			newRequest("GET", "/transformers/people/__duplicates"),
+			&dummyService{
+				initialised: true,
+				dataLoaded:  true,
+				duplicates:  []duplicateCluster{}},
+			http.StatusOK,
+			"application/json",
+			"[]\n"},
+		{"Duplicates - Service unavailable as data not loaded",
+			newRequest("GET", "/transformers/people/__duplicates"),
+			&dummyService{
+				initialised: true,
+				dataLoaded:  false},
 			http.StatusServiceUnavailable,
 			"application/json",
 			"{\"message\": \"Service Unavailable\"}\n"},
+		{"405 - duplicates",
+			newRequest("POST", "/transformers/people/__duplicates"),
+			&dummyService{
+				initialised: true,
+				dataLoaded:  true},
+			http.StatusMethodNotAllowed,
+			"application/json",
+			""},
+		{"Diff - Success",
+			newRequest("GET", "/transformers/people/__diff"),
+			&dummyService{
+				initialised: true,
+				dataLoaded:  true,
+				diff:        datasetDiff{CurrentCount: 1, PreviousCount: 1, Added: []string{testUUID}, Removed: []string{testUUID2}, Changed: []personDiff{}, Migrated: []personMigration{}}},
+			http.StatusOK,
+			"application/json",
+			"{\"currentCount\":1,\"previousCount\":1,\"added\":[\"" + testUUID + "\"],\"removed\":[\"" + testUUID2 + "\"],\"changed\":[],\"migrated\":[]}\n"},
+		{"Diff - Service unavailable while loading",
+			newRequest("GET", "/transformers/people/__diff"),
+			&dummyService{
+				initialised: true,
+				dataLoaded:  true,
+				loading:     true},
+			http.StatusServiceUnavailable,
+			"application/json",
+			"{\"message\": \"No previous load to diff against, people are loading or the last load was not served\"}\n"},
+		{"Diff - Error",
+			newRequest("GET", "/transformers/people/__diff"),
+			&dummyService{
+				initialised: true,
+				dataLoaded:  true,
+				err:         errors.New("Bucket not found")},
+			http.StatusInternalServerError,
+			"application/json",
+			"{\"message\": \"Bucket not found\"}\n"},
+		{"Snapshot export - Success",
+			newRequest("GET", "/transformers/people/__snapshot"),
+			&dummyService{
+				initialised: true,
+				dataLoaded:  true},
+			http.StatusOK,
+			"application/gzip",
+			"snapshot"},
+		{"Snapshot export - Service unavailable as data not loaded",
+			newRequest("GET", "/transformers/people/__snapshot"),
+			&dummyService{
+				initialised: true,
+				dataLoaded:  false},
+			http.StatusServiceUnavailable,
+			"application/json",
+			"{\"message\": \"Service Unavailable\"}\n"},
+		{"Snapshot import - Success",
+			newRequest("PUT", "/transformers/people/__snapshot"),
+			&dummyService{
+				initialised: true,
+				dataLoaded:  true,
+				people:      []person{{UUID: testUUID}, {UUID: testUUID2}}},
+			http.StatusOK,
+			"application/json",
+			"{\"message\": \"Imported 2 people\"}\n"},
+		{"Snapshot import - Invalid snapshot",
+			newRequest("PUT", "/transformers/people/__snapshot"),
+			&dummyService{
+				err:         errors.New("Snapshot is truncated, its trailer is missing"),
+				initialised: true,
+				dataLoaded:  true},
+			http.StatusBadRequest,
+			"application/json",
+			"{\"message\": \"Snapshot is truncated, its trailer is missing\"}\n"},
+		{"Snapshot import - Success after the first load failed",
+			newRequest("PUT", "/transformers/people/__snapshot"),
+			&dummyService{
+				initialised: true,
+				dataLoaded:  false,
+				people:      []person{{UUID: testUUID}}},
+			http.StatusOK,
+			"application/json",
+			"{\"message\": \"Imported 1 people\"}\n"},
+		{"Snapshot import - Service unavailable while loading for the first time",
+			newRequest("PUT", "/transformers/people/__snapshot"),
+			&dummyService{
+				initialised: true,
+				dataLoaded:  false,
+				loading:     true},
+			http.StatusServiceUnavailable,
+			"application/json",
+			"{\"message\": \"People are loading, retry the import once they are loaded\"}\n"},
+		{"Snapshot import - Service unavailable as not initialised",
+			newRequest("PUT", "/transformers/people/__snapshot"),
+			&dummyService{
+				initialised: false},
+			http.StatusServiceUnavailable,
+			"application/json",
+			"{\"message\": \"Service Unavailable\"}\n"},
+		{"Snapshot import - Service unavailable while reloading",
+			newRequest("PUT", "/transformers/people/__snapshot"),
+			&dummyService{
+				initialised: true,
+				dataLoaded:  true,
+				loading:     true},
+			http.StatusServiceUnavailable,
+			"application/json",
+			"{\"message\": \"People are loading, retry the import once they are loaded\"}\n"},
+		{"405 - snapshot",
+			newRequest("POST", "/transformers/people/__snapshot"),
+			&dummyService{
+				initialised: true,
+				dataLoaded:  true},
+			http.StatusMethodNotAllowed,
+			"application/json",
+			""},
 	}
 	for _, test := range tests {
 		wg.Add(1)
@@ -333,6 +659,89 @@ func TestReloadIsCalled(t *testing.T) {
 	assert.True(t, s.loadDBCalled)
 }
 
+func TestGetPeopleLinksHonoursForwardedHeaders(t *testing.T) {
+	rec := httptest.NewRecorder()
+	s := &dummyService{
+		initialised: true,
+		count:       1,
+		people:      []person{{UUID: testUUID}}}
+	req := newRequest("GET", "/transformers/people/__links")
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "semantic-up.ft.com, internal-host")
+	req.Header.Set("X-Forwarded-Prefix", "/__v1-people-transformer")
+	router(s).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var links []personLink
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &links))
+	assert.Len(t, links, 1)
+	assert.Equal(t, "https://semantic-up.ft.com/__v1-people-transformer/transformers/people/"+testUUID, links[0].APIURL)
+}
+
+func TestConditionalGetPersonByUUID(t *testing.T) {
+	s := &dummyService{
+		found:       true,
+		initialised: true,
+		people:      []person{{UUID: testUUID, PrefLabel: "Bob", Hash: "abc123"}}}
+
+	rec := httptest.NewRecorder()
+	router(s).ServeHTTP(rec, newRequest("GET", fmt.Sprintf("/transformers/people/%s", testUUID)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"abc123"`, rec.Header().Get("ETag"))
+
+	rec = httptest.NewRecorder()
+	req := newRequest("GET", fmt.Sprintf("/transformers/people/%s", testUUID))
+	req.Header.Set("If-None-Match", `"abc123"`)
+	router(s).ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	req = newRequest("GET", fmt.Sprintf("/transformers/people/%s", testUUID))
+	req.Header.Set("If-None-Match", `"somethingelse"`)
+	router(s).ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestConditionalGetPeople(t *testing.T) {
+	lastModified := time.Date(2017, time.May, 23, 10, 0, 0, 0, time.UTC)
+	s := &dummyService{
+		initialised: true,
+		dataLoaded:  true,
+		count:       2,
+		people:      []person{{UUID: testUUID}, {UUID: testUUID2}},
+		dataset:     datasetVersion{generation: 1, etag: `"dataset"`, lastModified: lastModified}}
+
+	for _, path := range []string{"/transformers/people", "/transformers/people/__ids", "/transformers/people/__links"} {
+		rec := httptest.NewRecorder()
+		router(s).ServeHTTP(rec, newRequest("GET", path))
+		assert.Equal(t, http.StatusOK, rec.Code, path)
+		assert.Equal(t, `"dataset"`, rec.Header().Get("ETag"), path)
+		assert.Equal(t, "Tue, 23 May 2017 10:00:00 GMT", rec.Header().Get("Last-Modified"), path)
+
+		rec = httptest.NewRecorder()
+		req := newRequest("GET", path)
+		req.Header.Set("If-None-Match", `"dataset"`)
+		router(s).ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNotModified, rec.Code, path)
+		assert.Empty(t, rec.Body.String(), path)
+
+		rec = httptest.NewRecorder()
+		req = newRequest("GET", path)
+		req.Header.Set("If-Modified-Since", "Tue, 23 May 2017 10:00:00 GMT")
+		router(s).ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNotModified, rec.Code, path)
+	}
+
+	s.dataLoaded = false
+	rec := httptest.NewRecorder()
+	req := newRequest("GET", "/transformers/people")
+	req.Header.Set("If-None-Match", `"dataset"`)
+	router(s).ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code, "Reloading data must not be validated against the previous load")
+	assert.Empty(t, rec.Header().Get("ETag"))
+}
+
 func newRequest(method, url string) *http.Request {
 	req, err := http.NewRequest(method, url, nil)
 	if err != nil {
@@ -350,6 +759,16 @@ type dummyService struct {
 	err          error
 	loadDBCalled bool
 	wg           *sync.WaitGroup
+	dataset      datasetVersion
+	diff         datasetDiff
+	loading      bool
+	rejectedLoad error
+	probeErr     error
+	dbErr        error
+	stale        error
+	quarantine   []quarantinedTerm
+	quality      qualityReport
+	duplicates   []duplicateCluster
 }
 
 func (s *dummyService) getPeople() (io.PipeReader, error) {
@@ -364,6 +783,17 @@ func (s *dummyService) getPeople() (io.PipeReader, error) {
 	return *pv, nil
 }
 
+func (s *dummyService) getPeopleAs(render personRenderer) (io.PipeReader, error) {
+	pv, pw := io.Pipe()
+	go func() {
+		for _, sub := range s.people {
+			render(pw, sub)
+		}
+		pw.Close()
+	}()
+	return *pv, nil
+}
+
 func (s *dummyService) getPeopleUUIDs() (io.PipeReader, error) {
 	pv, pw := io.Pipe()
 	go func() {
@@ -376,12 +806,16 @@ func (s *dummyService) getPeopleUUIDs() (io.PipeReader, error) {
 	return *pv, nil
 }
 
-func (s *dummyService) getPeopleLinks() (io.PipeReader, error) {
+func (s *dummyService) getPeopleLinks(fwd forwardedHeaders) (io.PipeReader, error) {
+	base, err := linksBaseURL("http://localhost:8080/transformers/people/", fwd)
+	if err != nil {
+		return io.PipeReader{}, err
+	}
 	pv, pw := io.Pipe()
 	go func() {
 		var links []personLink
 		for _, sub := range s.people {
-			links = append(links, personLink{APIURL: "http://localhost:8080/transformers/people/" + sub.UUID})
+			links = append(links, personLink{APIURL: buildAPIURL(base, sub.UUID)})
 		}
 		b, _ := json.Marshal(links)
 		log.Infof("Writing bytes... %v", string(b))
@@ -391,6 +825,56 @@ func (s *dummyService) getPeopleLinks() (io.PipeReader, error) {
 	return *pv, nil
 }
 
+func (s *dummyService) exportSnapshot(w io.Writer) error {
+	_, err := io.WriteString(w, "snapshot")
+	return err
+}
+
+func (s *dummyService) importSnapshot(r io.Reader) (int, error) {
+	if s.loading {
+		return 0, errAlreadyLoading
+	}
+	if s.err != nil {
+		return 0, s.err
+	}
+	return len(s.people), nil
+}
+
+func (s *dummyService) getDatasetDiff() (datasetDiff, bool, error) {
+	if s.err != nil {
+		return datasetDiff{}, false, s.err
+	}
+	return s.diff, !s.loading, nil
+}
+
+func (s *dummyService) checkRepository() error {
+	return s.probeErr
+}
+
+func (s *dummyService) checkDataFreshness() (string, error) {
+	return "People were last loaded from TME 1h0m0s ago", s.stale
+}
+
+func (s *dummyService) checkCacheDB() error {
+	return s.dbErr
+}
+
+func (s *dummyService) getQuarantine() ([]quarantinedTerm, error) {
+	return s.quarantine, s.err
+}
+
+func (s *dummyService) getQualityReport() (qualityReport, error) {
+	return s.quality, s.err
+}
+
+func (s *dummyService) getDuplicates() ([]duplicateCluster, error) {
+	return s.duplicates, s.err
+}
+
+func (s *dummyService) getRejectedLoad() error {
+	return s.rejectedLoad
+}
+
 func (s *dummyService) getCount() (int, error) {
 	return s.count, s.err
 }
@@ -399,6 +883,10 @@ func (s *dummyService) getPersonByUUID(uuid string) (person, bool, error) {
 	return s.people[0], s.found, nil
 }
 
+func (s *dummyService) getDatasetVersion() datasetVersion {
+	return s.dataset
+}
+
 func (s *dummyService) isInitialised() bool {
 	return s.initialised
 }
@@ -407,18 +895,35 @@ func (s *dummyService) isDataLoaded() bool {
 	return s.dataLoaded
 }
 
+func (s *dummyService) isLoading() bool {
+	return s.loading
+}
+
+func (s *dummyService) checkDataAge() error {
+	return s.stale
+}
+
 func (s *dummyService) Shutdown() error {
 	return s.err
 }
 
-func (s *dummyService) reloadDB() error {
+func (s *dummyService) reloadDB(ctx context.Context) error {
 	defer s.wg.Done()
 	s.loadDBCalled = true
 	return s.err
 }
 
+func (s *dummyService) startReload(ctx context.Context) bool {
+	if s.loading {
+		return false
+	}
+	go s.reloadDB(ctx)
+	return true
+}
+
 func router(s PeopleService) *mux.Router {
 	servicesRouter := mux.NewRouter()
+	servicesRouter.Use(TracingHandler)
 	handler := NewPeopleHandler(s)
 
 	getPeopleSubrouter := servicesRouter.Path("/transformers/people").Subrouter()
@@ -433,6 +938,35 @@ func router(s PeopleService) *mux.Router {
 	personIDsSubrouter.Methods("GET").HandlerFunc(handler.GetPeopleUUIDs)
 	personIDsSubrouter.NewRoute().HandlerFunc(handler.OnlyGetAllowed)
 
+	personLinksSubrouter := servicesRouter.Path("/transformers/people/__links").Subrouter()
+	personLinksSubrouter.Methods("GET").HandlerFunc(handler.GetPeopleLinks)
+	personLinksSubrouter.NewRoute().HandlerFunc(handler.OnlyGetAllowed)
+
+	exportSubrouter := servicesRouter.Path("/transformers/people/__export").Subrouter()
+	exportSubrouter.Methods("GET").HandlerFunc(handler.Export)
+	exportSubrouter.NewRoute().HandlerFunc(handler.OnlyGetAllowed)
+
+	diffSubrouter := servicesRouter.Path("/transformers/people/__diff").Subrouter()
+	diffSubrouter.Methods("GET").HandlerFunc(handler.GetDiff)
+	diffSubrouter.NewRoute().HandlerFunc(handler.OnlyGetAllowed)
+
+	errorsSubrouter := servicesRouter.Path("/transformers/people/__errors").Subrouter()
+	errorsSubrouter.Methods("GET").HandlerFunc(handler.GetErrors)
+	errorsSubrouter.NewRoute().HandlerFunc(handler.OnlyGetAllowed)
+
+	qualitySubrouter := servicesRouter.Path("/transformers/people/__quality").Subrouter()
+	qualitySubrouter.Methods("GET").HandlerFunc(handler.GetQuality)
+	qualitySubrouter.NewRoute().HandlerFunc(handler.OnlyGetAllowed)
+
+	duplicatesSubrouter := servicesRouter.Path("/transformers/people/__duplicates").Subrouter()
+	duplicatesSubrouter.Methods("GET").HandlerFunc(handler.GetDuplicates)
+	duplicatesSubrouter.NewRoute().HandlerFunc(handler.OnlyGetAllowed)
+
+	snapshotSubrouter := servicesRouter.Path("/transformers/people/__snapshot").Subrouter()
+	snapshotSubrouter.Methods("GET").HandlerFunc(handler.GetSnapshot)
+	snapshotSubrouter.Methods("PUT").HandlerFunc(handler.PutSnapshot)
+	snapshotSubrouter.NewRoute().HandlerFunc(handler.OnlyGetOrPutAllowed)
+
 	personByUUIDSubrouter := servicesRouter.Path("/transformers/people/{uuid:([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})}").Subrouter()
 	personByUUIDSubrouter.Methods("GET").HandlerFunc(handler.GetPersonByUUID)
 	personByUUIDSubrouter.NewRoute().HandlerFunc(handler.OnlyGetAllowed)
@@ -441,7 +975,7 @@ func router(s PeopleService) *mux.Router {
 	reloadSubrouter.Methods("POST").HandlerFunc(handler.Reload)
 	reloadSubrouter.NewRoute().HandlerFunc(handler.OnlyPostAllowed)
 
-	servicesRouter.HandleFunc("/__health", v1a.Handler("V1 People Transformer Healthchecks", "Checks for the health of the service", handler.HealthCheck()))
+	servicesRouter.HandleFunc("/__health", v1a.Handler("V1 People Transformer Healthchecks", "Checks for the health of the service", handler.HealthChecks()...))
 	g2gHandler := status.NewGoodToGoHandler(gtg.StatusChecker(handler.G2GCheck))
 	servicesRouter.HandleFunc(status.GTGPath, g2gHandler)
 	return servicesRouter
@@ -0,0 +1,68 @@
+package people
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+//WithMaxDataAge reports the people as stale in the health checks when they were last loaded from TME longer ago than maxAge
+func WithMaxDataAge(maxAge time.Duration) ServiceOption {
+	return func(s *peopleServiceImpl) {
+		s.maxDataAge = maxAge
+	}
+}
+
+func (s *peopleServiceImpl) checkRepository() error {
+	if s.probe == nil {
+		return nil
+	}
+	return s.probe()
+}
+
+//checkDataFreshness fails until a load from TME has been served, and once it is older than the max age
+func (s *peopleServiceImpl) checkDataFreshness() (string, error) {
+	s.RLock()
+	defer s.RUnlock()
+	if s.lastLoad.IsZero() {
+		return "", errors.New("No load from TME served since the service started")
+	}
+	if err := s.dataAgeError(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("People were last loaded from TME %v ago", time.Since(s.lastLoad)), nil
+}
+
+//checkDataAge only fails once a load from TME is older than the max age, people served from the cache file on start are not stale
+func (s *peopleServiceImpl) checkDataAge() error {
+	s.RLock()
+	defer s.RUnlock()
+	if s.lastLoad.IsZero() {
+		return nil
+	}
+	return s.dataAgeError()
+}
+
+//dataAgeError must be called holding the lock
+func (s *peopleServiceImpl) dataAgeError() error {
+	if age := time.Since(s.lastLoad); s.maxDataAge > 0 && age > s.maxDataAge {
+		return fmt.Errorf("People were last loaded from TME %v ago, more than %v", age, s.maxDataAge)
+	}
+	return nil
+}
+
+func (s *peopleServiceImpl) checkCacheDB() error {
+	s.RLock()
+	defer s.RUnlock()
+	if s.db == nil {
+		return errors.New("Cache file not open")
+	}
+	return s.db.View(func(tx *bolt.Tx) error {
+		if s.dataLoaded && tx.Bucket([]byte(s.served().people)) == nil {
+			return fmt.Errorf("Bucket %v not found!", s.served().people)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,55 @@
+package people
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthChecks(t *testing.T) {
+	tmpfile := getTempFile(t)
+	defer os.Remove(tmpfile.Name())
+	repo := dummyRepo{terms: []term{{CanonicalName: "Bob", RawID: "bob"}}}
+	probeErr := errors.New("TME rejected the credentials with status 401")
+	listener := &recordingListener{}
+	service := NewPeopleService(&repo, "/base/url", "taxonomy_string", 1, tmpfile.Name(),
+		WithRepositoryProbe(func() error { return probeErr }), WithMaxDataAge(time.Hour), withLoadListener(listener))
+	waitTillInit(t, service)
+	listener.waitForLoads(t, 1)
+
+	assert.Equal(t, probeErr, service.checkRepository())
+	message, err := service.checkDataFreshness()
+	assert.NoError(t, err)
+	assert.Contains(t, message, "People were last loaded from TME")
+	assert.NoError(t, service.checkCacheDB())
+
+	impl := service.(*peopleServiceImpl)
+	impl.Lock()
+	impl.lastLoad = time.Now().Add(-2 * time.Hour)
+	impl.Unlock()
+	_, err = service.checkDataFreshness()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "more than 1h0m0s")
+	}
+
+	repo.err = errors.New("TME is down")
+	repo.count = 0
+	assert.Error(t, service.reloadDB(context.Background()))
+	_, err = service.checkDataFreshness()
+	assert.Error(t, err, "A failed load does not refresh the people")
+
+	service.Shutdown()
+	assert.Error(t, service.checkCacheDB())
+}
+
+func TestHealthChecksBeforeFirstLoad(t *testing.T) {
+	service := peopleServiceImpl{}
+	assert.NoError(t, service.checkRepository(), "Without a probe the repository is not checked")
+	_, err := service.checkDataFreshness()
+	assert.EqualError(t, err, "No load from TME served since the service started")
+	assert.EqualError(t, service.checkCacheDB(), "Cache file not open")
+}
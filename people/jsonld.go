@@ -0,0 +1,47 @@
+package people
+
+const (
+	mediaTypeJSONLD = "application/ld+json"
+	thingsURIPrefix = "http://api.ft.com/things/"
+	thingType       = "http://www.ft.com/ontology/thing/Thing"
+	conceptType     = "http://www.ft.com/ontology/concept/Concept"
+	personType      = "http://www.ft.com/ontology/person/Person"
+)
+
+//personTypes is the type hierarchy of a person, from the most generic type down to its direct type
+var personTypes = []string{thingType, conceptType, personType}
+
+var jsonLDContext = map[string]interface{}{
+	"@vocab":      "http://www.ft.com/ontology/",
+	"skos":        "http://www.w3.org/2004/02/skos/core#",
+	"types":       "@type",
+	"directType":  map[string]string{"@id": "directType", "@type": "@id"},
+	"prefLabel":   "skos:prefLabel",
+	"aliases":     "skos:altLabel",
+	"identifiers": map[string]string{"@id": "identifiers", "@container": "@index"},
+}
+
+var peopleJSONLD = representation{mediaType: mediaTypeJSONLD, variant: "ld", view: func(p person) interface{} { return newPersonJSONLD(p) }}
+
+func thingURI(uuid string) string {
+	return thingsURIPrefix + uuid
+}
+
+func newPersonJSONLD(p person) personJSONLD {
+	identifiers := map[string][]string{}
+	if len(p.AlternativeIdentifiers.Uuids) > 0 {
+		identifiers[uppAuthority] = p.AlternativeIdentifiers.Uuids
+	}
+	if len(p.AlternativeIdentifiers.TME) > 0 {
+		identifiers[tmeAuthority] = p.AlternativeIdentifiers.TME
+	}
+	return personJSONLD{
+		Context:     jsonLDContext,
+		ID:          thingURI(p.UUID),
+		Types:       personTypes,
+		DirectType:  personTypes[len(personTypes)-1],
+		PrefLabel:   p.PrefLabel,
+		Aliases:     p.Aliases,
+		Identifiers: identifiers,
+	}
+}
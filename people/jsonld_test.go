@@ -0,0 +1,68 @@
+package people
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPersonJSONLD(t *testing.T) {
+	p := transformPerson(term{CanonicalName: "Bob", RawID: "bob", Aliases: aliases{Alias: []alias{{Name: "Robert"}}}}, taxonomyName, LegacyUUIDScheme)
+	ld := newPersonJSONLD(p)
+
+	assert.Equal(t, "http://api.ft.com/things/"+p.UUID, ld.ID)
+	assert.Equal(t, []string{thingType, conceptType, personType}, ld.Types)
+	assert.Equal(t, personType, ld.DirectType)
+	assert.Equal(t, "Bob", ld.PrefLabel)
+	assert.Equal(t, []string{"Robert"}, ld.Aliases)
+	assert.Equal(t, map[string][]string{
+		"http://api.ft.com/system/FT-UPP": {p.UUID},
+		"http://api.ft.com/system/FT-TME": {"Ym9i-dGF4b25vbXlfbmFtZQ=="},
+	}, ld.Identifiers)
+}
+
+func TestGetPersonByUUIDJSONLD(t *testing.T) {
+	s := &dummyService{
+		found:       true,
+		initialised: true,
+		people:      []person{{UUID: testUUID, PrefLabel: "Bob", Type: "Person", AlternativeIdentifiers: alternativeIdentifiers{Uuids: []string{testUUID}}}}}
+
+	rec := httptest.NewRecorder()
+	req := newRequest("GET", fmt.Sprintf("/transformers/people/%s", testUUID))
+	req.Header.Set("Accept", "application/ld+json")
+	router(s).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, mediaTypeJSONLD, rec.Header().Get("Content-Type"))
+	var doc map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.Equal(t, "http://api.ft.com/things/"+testUUID, doc["@id"])
+	assert.Contains(t, doc, "@context")
+	assert.Equal(t, map[string]interface{}{uppAuthority: []interface{}{testUUID}}, doc["identifiers"])
+}
+
+func TestGetPeopleJSONLD(t *testing.T) {
+	s := &dummyService{
+		initialised: true,
+		count:       2,
+		people:      []person{{UUID: testUUID}, {UUID: testUUID2}}}
+
+	rec := httptest.NewRecorder()
+	req := newRequest("GET", "/transformers/people")
+	req.Header.Set("Accept", "application/ld+json")
+	router(s).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, mediaTypeJSONLD, rec.Header().Get("Content-Type"))
+	dec := json.NewDecoder(rec.Body)
+	for _, uuid := range []string{testUUID, testUUID2} {
+		var ld personJSONLD
+		assert.NoError(t, dec.Decode(&ld))
+		assert.Equal(t, thingURI(uuid), ld.ID)
+	}
+	assert.False(t, dec.More())
+}
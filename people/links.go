@@ -0,0 +1,56 @@
+package people
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+//forwardedHeaders holds the X-Forwarded-* values set by the proxies in front of the transformer
+type forwardedHeaders struct {
+	proto  string
+	host   string
+	prefix string
+}
+
+func forwardedHeadersFromRequest(req *http.Request) forwardedHeaders {
+	return forwardedHeaders{
+		proto:  firstHeaderValue(req.Header.Get("X-Forwarded-Proto")),
+		host:   firstHeaderValue(req.Header.Get("X-Forwarded-Host")),
+		prefix: firstHeaderValue(req.Header.Get("X-Forwarded-Prefix")),
+	}
+}
+
+//firstHeaderValue returns the left-most entry of a comma separated header, which is the one set by the outermost proxy
+func firstHeaderValue(value string) string {
+	return strings.TrimSpace(strings.Split(value, ",")[0])
+}
+
+//linksBaseURL applies the forwarded headers on top of the configured base url
+func linksBaseURL(baseURL string, fwd forwardedHeaders) (*url.URL, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if fwd.proto != "" {
+		base.Scheme = fwd.proto
+	}
+	if fwd.host != "" {
+		base.Host = fwd.host
+		if base.Scheme == "" {
+			base.Scheme = "http"
+		}
+	}
+	if fwd.prefix != "" {
+		base.Path = path.Join("/", fwd.prefix, base.Path)
+	}
+	return base, nil
+}
+
+func buildAPIURL(base *url.URL, uuid string) string {
+	apiURL := *base
+	apiURL.Path = path.Join(base.Path, uuid)
+	apiURL.RawPath = ""
+	return apiURL.String()
+}
@@ -0,0 +1,64 @@
+package people
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildAPIURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseURL  string
+		fwd      forwardedHeaders
+		expected string
+	}{
+		{"Base url with trailing slash",
+			"http://localhost:8080/transformers/people/",
+			forwardedHeaders{},
+			"http://localhost:8080/transformers/people/" + testUUID},
+		{"Base url without trailing slash",
+			"http://localhost:8080/transformers/people",
+			forwardedHeaders{},
+			"http://localhost:8080/transformers/people/" + testUUID},
+		{"Relative base url",
+			"/base/url",
+			forwardedHeaders{},
+			"/base/url/" + testUUID},
+		{"Forwarded proto and host",
+			"http://localhost:8080/transformers/people/",
+			forwardedHeaders{proto: "https", host: "semantic-up.ft.com"},
+			"https://semantic-up.ft.com/transformers/people/" + testUUID},
+		{"Forwarded host on relative base url",
+			"/transformers/people/",
+			forwardedHeaders{host: "semantic-up.ft.com"},
+			"http://semantic-up.ft.com/transformers/people/" + testUUID},
+		{"Forwarded prefix",
+			"http://localhost:8080/transformers/people/",
+			forwardedHeaders{prefix: "/__v1-people-transformer/"},
+			"http://localhost:8080/__v1-people-transformer/transformers/people/" + testUUID},
+	}
+	for _, test := range tests {
+		base, err := linksBaseURL(test.baseURL, test.fwd)
+		assert.NoError(t, err, test.name)
+		assert.Equal(t, test.expected, buildAPIURL(base, testUUID), test.name)
+	}
+}
+
+func TestForwardedHeadersFromRequest(t *testing.T) {
+	req, err := http.NewRequest("GET", "/transformers/people/__links", nil)
+	assert.NoError(t, err)
+	req.Header.Set("X-Forwarded-Proto", "https, http")
+	req.Header.Set("X-Forwarded-Host", " semantic-up.ft.com ")
+
+	fwd := forwardedHeadersFromRequest(req)
+	assert.Equal(t, "https", fwd.proto)
+	assert.Equal(t, "semantic-up.ft.com", fwd.host)
+	assert.Equal(t, "", fwd.prefix)
+}
+
+func TestLinksBaseURLWithInvalidURL(t *testing.T) {
+	_, err := linksBaseURL("http://[::1", forwardedHeaders{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,32 @@
+package people
+
+import (
+	"context"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//loadLoggerKey holds the logger of a load in its context, so every event logged by the load carries its ids
+type loadLoggerKey struct{}
+
+func withLoadLogger(ctx context.Context, logger *log.Entry) context.Context {
+	return context.WithValue(ctx, loadLoggerKey{}, logger)
+}
+
+//loadLogger is the logger of the load of the context, the standard logger outside of a load
+func loadLogger(ctx context.Context) *log.Entry {
+	if logger, found := ctx.Value(loadLoggerKey{}).(*log.Entry); found {
+		return logger
+	}
+	return log.NewEntry(log.StandardLogger())
+}
+
+//event names what is logged, so the events of a load can be queried
+func event(logger *log.Entry, name string) *log.Entry {
+	return logger.WithField("event", name)
+}
+
+func durationMillis(d time.Duration) int64 {
+	return int64(d / time.Millisecond)
+}
@@ -0,0 +1,87 @@
+package people
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/Financial-Times/transactionid-utils-go"
+	log "github.com/Sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingHook struct {
+	sync.Mutex
+	entries []*log.Entry
+}
+
+func (h *recordingHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (h *recordingHook) Fire(entry *log.Entry) error {
+	h.Lock()
+	defer h.Unlock()
+	h.entries = append(h.entries, entry)
+	return nil
+}
+
+//eventsOf lists the events logged with the transaction id
+func (h *recordingHook) eventsOf(transactionID string) []*log.Entry {
+	h.Lock()
+	defer h.Unlock()
+	var events []*log.Entry
+	for _, entry := range h.entries {
+		if entry.Data["transaction_id"] == transactionID && entry.Data["event"] != nil {
+			events = append(events, entry)
+		}
+	}
+	return events
+}
+
+func TestLoadEventsAreCorrelated(t *testing.T) {
+	hook := &recordingHook{}
+	previous := log.StandardLogger().ReplaceHooks(log.LevelHooks{})
+	log.AddHook(hook)
+	level := log.GetLevel()
+	log.SetLevel(log.DebugLevel)
+	defer func() {
+		log.StandardLogger().ReplaceHooks(previous)
+		log.SetLevel(level)
+	}()
+
+	tmpfile := getTempFile(t)
+	defer os.Remove(tmpfile.Name())
+	repo := dummyRepo{terms: []term{{CanonicalName: "Bob", RawID: "bob"}, {CanonicalName: "Fred", RawID: "fred"}}}
+	listener := &recordingListener{}
+	service := NewPeopleService(&repo, "/base/url", "taxonomy_string", 1, tmpfile.Name(), withLoadListener(listener))
+	defer service.Shutdown()
+	listener.waitForLoads(t, 1)
+
+	repo.count = 0
+	assert.NoError(t, service.reloadDB(transactionidutils.TransactionAwareContext(context.Background(), "tid_logged")))
+	load := listener.waitForLoads(t, 2)
+
+	var names []string
+	for _, entry := range hook.eventsOf("tid_logged") {
+		names = append(names, entry.Data["event"].(string))
+		assert.Equal(t, load.loadID, entry.Data["load_id"], entry.Message)
+	}
+	assert.Equal(t, []string{"load_started", "tme_page_fetched", "terms_transformed", "tme_page_fetched", "terms_transformed", "tme_fetch_finished", "people_stored", "load_finished"}, filterEvents(names, "batch_stored"))
+
+	finished := hook.eventsOf("tid_logged")[len(names)-1]
+	assert.Contains(t, finished.Data, "duration_ms")
+	assert.NotEqual(t, load.loadID, listener.waitForLoads(t, 1).loadID, "Every load has its own id")
+}
+
+//filterEvents drops the events logged concurrently with the others
+func filterEvents(names []string, dropped string) []string {
+	var filtered []string
+	for _, name := range names {
+		if name != dropped {
+			filtered = append(filtered, name)
+		}
+	}
+	return filtered
+}
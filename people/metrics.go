@@ -0,0 +1,18 @@
+package people
+
+import (
+	"github.com/rcrowley/go-metrics"
+)
+
+//The load metrics are registered next to the HTTP ones, so they are output to Graphite with them
+var (
+	tmePageFetchTimer    = metrics.GetOrRegisterTimer("people.load.tme.page.fetch", metrics.DefaultRegistry)
+	tmePagesFetched      = metrics.GetOrRegisterCounter("people.load.tme.pages", metrics.DefaultRegistry)
+	termsTransformed     = metrics.GetOrRegisterCounter("people.load.terms.transformed", metrics.DefaultRegistry)
+	transformationErrors = metrics.GetOrRegisterCounter("people.load.transformation.errors", metrics.DefaultRegistry)
+	cacheBatchWriteTimer = metrics.GetOrRegisterTimer("people.load.cache.batch.write", metrics.DefaultRegistry)
+	peopleStored         = metrics.GetOrRegisterCounter("people.load.people.stored", metrics.DefaultRegistry)
+	loadDurationTimer    = metrics.GetOrRegisterTimer("people.load.duration", metrics.DefaultRegistry)
+	datasetSize          = metrics.GetOrRegisterGauge("people.dataset.size", metrics.DefaultRegistry)
+	lastLoadTimestamp    = metrics.GetOrRegisterGauge("people.load.last.timestamp", metrics.DefaultRegistry)
+)
@@ -0,0 +1,28 @@
+package people
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMetrics(t *testing.T) {
+	tmpfile := getTempFile(t)
+	defer os.Remove(tmpfile.Name())
+	pages, transformed, stored := tmePagesFetched.Count(), termsTransformed.Count(), peopleStored.Count()
+	fetches, writes, loads := tmePageFetchTimer.Count(), cacheBatchWriteTimer.Count(), loadDurationTimer.Count()
+
+	repo := dummyRepo{terms: []term{{CanonicalName: "Bob", RawID: "bob"}, {CanonicalName: "Fred", RawID: "fred"}}}
+	listener := &recordingListener{}
+	service := NewPeopleService(&repo, "/base/url", "taxonomy_string", 1, tmpfile.Name(), withLoadListener(listener))
+	defer service.Shutdown()
+	listener.waitForLoads(t, 1)
+
+	assert.Equal(t, int64(2), tmePagesFetched.Count()-pages)
+	assert.Equal(t, int64(3), tmePageFetchTimer.Count()-fetches, "The empty last page is timed too")
+	assert.Equal(t, int64(2), termsTransformed.Count()-transformed)
+	assert.Equal(t, int64(2), peopleStored.Count()-stored)
+	assert.Equal(t, int64(2), cacheBatchWriteTimer.Count()-writes)
+	assert.Equal(t, int64(1), loadDurationTimer.Count()-loads)
+}
@@ -0,0 +1,32 @@
+package people
+
+import (
+	"github.com/boltdb/bolt"
+)
+
+//WithUUIDScheme gives the people loaded their UUID in the scheme instead of the legacy one
+func WithUUIDScheme(scheme UUIDScheme) ServiceOption {
+	return func(s *peopleServiceImpl) {
+		s.uuidScheme = scheme
+	}
+}
+
+//WithUUIDMigration records the UUID people had in the previous scheme in their alternativeIdentifiers.uuids, and serves them by it too
+func WithUUIDMigration(previous UUIDScheme) ServiceOption {
+	return func(s *peopleServiceImpl) {
+		s.migratedFrom = append(s.migratedFrom, previous)
+	}
+}
+
+//putAliases maps the other UUIDs of the person to the one it is stored by
+func putAliases(bucket *bolt.Bucket, p person) error {
+	for _, alias := range p.AlternativeIdentifiers.Uuids {
+		if alias == p.UUID {
+			continue
+		}
+		if err := bucket.Put([]byte(alias), []byte(p.UUID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
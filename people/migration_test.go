@@ -0,0 +1,87 @@
+package people
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+const fredLegacyUUID = "28d66fcc-bb56-363d-80c1-f2d957ef58cf"
+
+func TestPeopleAreServedByEitherUUIDWhileMigrating(t *testing.T) {
+	tmpfile := getTempFile(t)
+	defer os.Remove(tmpfile.Name())
+	repo := dummyRepo{terms: []term{{CanonicalName: "Bob", RawID: "bob"}, {CanonicalName: "Fred", RawID: "fred"}}}
+	v5 := UUIDScheme{Version: 5, Namespace: uuid.NameSpace_URL}
+	listener := &recordingListener{}
+	service := NewPeopleService(&repo, "/base/url", "taxonomy_string", 1, tmpfile.Name(), withLoadListener(listener), WithUUIDScheme(v5), WithUUIDMigration(LegacyUUIDScheme))
+	assert.NoError(t, listener.waitForLoads(t, 1).err)
+	assertCount(t, service, 2)
+
+	fredUUID := transformPerson(term{RawID: "fred"}, "taxonomy_string", v5).UUID
+	for _, lookup := range []string{fredUUID, fredLegacyUUID} {
+		p, found, err := service.getPersonByUUID(lookup)
+		assert.NoError(t, err)
+		assert.True(t, found, lookup)
+		assert.Equal(t, fredUUID, p.UUID)
+		assert.Equal(t, []string{fredUUID, fredLegacyUUID}, p.AlternativeIdentifiers.Uuids)
+	}
+	assert.NoError(t, service.Shutdown())
+
+	var snapshot bytes.Buffer
+	assert.NoError(t, ExportSnapshot(tmpfile.Name(), &snapshot))
+	imported := getTempFile(t)
+	defer os.Remove(imported.Name())
+	_, err := ImportSnapshot(imported.Name(), &snapshot)
+	assert.NoError(t, err)
+
+	fromCache := NewPeopleService(&dummyRepo{}, "/base/url", "taxonomy_string", 1, imported.Name(), WithCachedDataOnStart())
+	defer fromCache.Shutdown()
+	waitTillDataLoaded(t, fromCache)
+	p, found, err := fromCache.getPersonByUUID(fredLegacyUUID)
+	assert.NoError(t, err)
+	assert.True(t, found, "The UUIDs migrated from are kept by snapshots")
+	assert.Equal(t, fredUUID, p.UUID)
+}
+
+func TestSwitchingUUIDSchemeMigratesThePeopleServed(t *testing.T) {
+	tmpfile := getTempFile(t)
+	defer os.Remove(tmpfile.Name())
+	repo := dummyRepo{terms: []term{{CanonicalName: "Bob", RawID: "bob"}, {CanonicalName: "Fred", RawID: "fred"}}}
+	listener := &recordingListener{}
+	service := NewPeopleService(&repo, "/base/url", "taxonomy_string", 1, tmpfile.Name(), withLoadListener(listener))
+	defer service.Shutdown()
+	assert.NoError(t, listener.waitForLoads(t, 1).err)
+
+	v5 := UUIDScheme{Version: 5, Namespace: uuid.NameSpace_URL}
+	impl := service.(*peopleServiceImpl)
+	impl.Lock()
+	impl.uuidScheme = v5
+	impl.migratedFrom = []UUIDScheme{LegacyUUIDScheme}
+	impl.Unlock()
+	repo.count = 0
+	assert.NoError(t, service.reloadDB(context.Background()))
+	second := listener.waitForLoads(t, 2)
+	assert.NoError(t, second.err)
+	assert.Equal(t, 0, second.countOf(personRemoved), "The people are still served by their legacy UUID")
+	assert.Equal(t, 2, second.countOf(personAdded))
+	assert.Equal(t, 2, second.countOf(personMigrated))
+	fredUUID := transformPerson(term{RawID: "fred"}, "taxonomy_string", v5).UUID
+	for _, c := range second.changes {
+		if c.uuid == fredLegacyUUID {
+			assert.Equal(t, personMigrated, c.change)
+			assert.Equal(t, fredUUID, c.migratedTo)
+			assert.Contains(t, string(c.marshalledPerson), fredUUID)
+		}
+	}
+
+	diff, available, err := service.getDatasetDiff()
+	assert.NoError(t, err)
+	assert.True(t, available)
+	assert.Empty(t, diff.Removed)
+	assert.Contains(t, diff.Migrated, personMigration{UUID: fredLegacyUUID, MigratedTo: fredUUID})
+}
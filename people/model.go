@@ -8,6 +8,7 @@ type person struct {
 	Type                   string                 `json:"type"`
 	AlternativeIdentifiers alternativeIdentifiers `json:"alternativeIdentifiers,omitempty"`
 	Aliases                []string               `json:"aliases,omitempty"`
+	Hash                   string                 `json:"-"`
 }
 
 type alternativeIdentifiers struct {
@@ -22,3 +23,40 @@ type personLink struct {
 type personUUID struct {
 	UUID string `json:"ID"`
 }
+
+//personV2 is the representation served for application/vnd.ft.people.v2+json
+type personV2 struct {
+	UUID        string            `json:"uuid"`
+	PrefLabel   string            `json:"prefLabel"`
+	Type        string            `json:"type"`
+	Identifiers []identifier      `json:"identifiers"`
+	Aliases     []structuredAlias `json:"aliases,omitempty"`
+	Provenance  []provenance      `json:"provenance,omitempty"`
+}
+
+type identifier struct {
+	Authority       string `json:"authority"`
+	IdentifierValue string `json:"identifierValue"`
+}
+
+type structuredAlias struct {
+	Label string `json:"label"`
+	Type  string `json:"type"`
+}
+
+type provenance struct {
+	Authority string `json:"authority"`
+	Taxonomy  string `json:"taxonomy,omitempty"`
+	TermID    string `json:"termId,omitempty"`
+}
+
+//personJSONLD is the linked data concept representation served for application/ld+json
+type personJSONLD struct {
+	Context     map[string]interface{} `json:"@context"`
+	ID          string                 `json:"@id"`
+	Types       []string               `json:"types"`
+	DirectType  string                 `json:"directType"`
+	PrefLabel   string                 `json:"prefLabel"`
+	Aliases     []string               `json:"aliases,omitempty"`
+	Identifiers map[string][]string    `json:"identifiers"`
+}
@@ -0,0 +1,11 @@
+package people
+
+//ServiceOption configures the optional behaviour of the people service
+type ServiceOption func(s *peopleServiceImpl)
+
+//WithCachedDataOnStart serves the people found in the cache file on start, e.g. after a snapshot import, only loading from TME when it is empty
+func WithCachedDataOnStart() ServiceOption {
+	return func(s *peopleServiceImpl) {
+		s.cachedOnStart = true
+	}
+}
@@ -0,0 +1,34 @@
+package people
+
+import (
+	"encoding/json"
+	"os"
+)
+
+//Overrides are the decisions taken by hand about the people loaded from TME
+type Overrides struct {
+	//Canonical lists the UUID to keep of each cluster of duplicate people
+	Canonical []string `json:"canonical"`
+}
+
+//LoadOverrides reads the overrides from a json file
+func LoadOverrides(fileName string) (Overrides, error) {
+	var overrides Overrides
+	f, err := os.Open(fileName)
+	if err != nil {
+		return overrides, err
+	}
+	defer f.Close()
+	err = json.NewDecoder(f).Decode(&overrides)
+	return overrides, err
+}
+
+//WithOverrides applies the overrides to the people served
+func WithOverrides(overrides Overrides) ServiceOption {
+	return func(s *peopleServiceImpl) {
+		s.canonical = map[string]bool{}
+		for _, uuid := range overrides.Canonical {
+			s.canonical[uuid] = true
+		}
+	}
+}
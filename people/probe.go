@@ -0,0 +1,55 @@
+package people
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+)
+
+//Probe checks the people can be fetched, without loading them
+type Probe func() error
+
+//WithRepositoryProbe reports the probe of the repository in the health checks
+func WithRepositoryProbe(probe Probe) ServiceOption {
+	return func(s *peopleServiceImpl) {
+		s.probe = probe
+	}
+}
+
+//NewTMEProbe asks TME for a single term with the credentials the repository uses
+func NewTMEProbe(client httpClient, tmeBaseURL string, username string, password string, token string, taxonomyName string) Probe {
+	url := strings.TrimSuffix(tmeBaseURL, "/") + "/rs/authorityfiles/" + taxonomyName + "/terms?maximumRecords=1&startRecord=0"
+	return func() error {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Add("Accept", "application/xml;charset=utf-8")
+		req.SetBasicAuth(username, password)
+		req.Header.Add("X-Coco-Auth", token)
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("TME is unreachable: %v", err)
+		}
+		defer resp.Body.Close()
+		io.Copy(ioutil.Discard, resp.Body)
+		switch resp.StatusCode {
+		case http.StatusOK:
+			return nil
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return fmt.Errorf("TME rejected the credentials with status %v", resp.StatusCode)
+		}
+		return fmt.Errorf("TME returned status %v", resp.StatusCode)
+	}
+}
+
+//NewFileProbe checks the export read by a file repository is still there
+func NewFileProbe(path string) Probe {
+	return func() error {
+		_, err := os.Stat(path)
+		return err
+	}
+}
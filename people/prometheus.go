@@ -0,0 +1,67 @@
+package people
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rcrowley/go-metrics"
+)
+
+var invalidMetricNameChars = regexp.MustCompile("[^a-zA-Z0-9_]+")
+
+var summaryQuantiles = []float64{0.5, 0.95, 0.99}
+
+//metricsCollector exposes every metric of a go-metrics registry to Prometheus, so the HTTP and load metrics are recorded once for both Graphite and Prometheus
+type metricsCollector struct {
+	registry metrics.Registry
+}
+
+//NewMetricsCollector collects the metrics of the registry as they are when Prometheus scrapes them, timers in seconds
+func NewMetricsCollector(registry metrics.Registry) prometheus.Collector {
+	return &metricsCollector{registry: registry}
+}
+
+//Describe sends no descriptions, the metrics of the registry are only known when collected
+func (c *metricsCollector) Describe(ch chan<- *prometheus.Desc) {
+}
+
+func (c *metricsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.registry.Each(func(name string, i interface{}) {
+		name = prometheusName(name)
+		switch metric := i.(type) {
+		case metrics.Counter:
+			ch <- constMetric(name+"_total", prometheus.CounterValue, float64(metric.Count()))
+		case metrics.Gauge:
+			ch <- constMetric(name, prometheus.GaugeValue, float64(metric.Value()))
+		case metrics.GaugeFloat64:
+			ch <- constMetric(name, prometheus.GaugeValue, metric.Value())
+		case metrics.Meter:
+			ch <- constMetric(name+"_total", prometheus.CounterValue, float64(metric.Count()))
+		case metrics.Timer:
+			snapshot := metric.Snapshot()
+			collectQuantiles(ch, name+"_seconds", snapshot.Count(), snapshot.Percentiles(summaryQuantiles), float64(time.Second))
+		case metrics.Histogram:
+			snapshot := metric.Snapshot()
+			collectQuantiles(ch, name, snapshot.Count(), snapshot.Percentiles(summaryQuantiles), 1)
+		}
+	})
+}
+
+func prometheusName(name string) string {
+	return invalidMetricNameChars.ReplaceAllString(name, "_")
+}
+
+func constMetric(name string, valueType prometheus.ValueType, value float64) prometheus.Metric {
+	return prometheus.MustNewConstMetric(prometheus.NewDesc(name, name, nil, nil), valueType, value)
+}
+
+//collectQuantiles sends the count and the quantiles of a timer or histogram, but not a summary: go-metrics only sums the values of its sample, not all of them
+func collectQuantiles(ch chan<- prometheus.Metric, name string, count int64, percentiles []float64, unit float64) {
+	ch <- constMetric(name+"_count", prometheus.CounterValue, float64(count))
+	desc := prometheus.NewDesc(name, name, []string{"quantile"}, nil)
+	for i, q := range summaryQuantiles {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, percentiles[i]/unit, strconv.FormatFloat(q, 'g', -1, 64))
+	}
+}
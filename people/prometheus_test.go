@@ -0,0 +1,51 @@
+package people
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsCollector(t *testing.T) {
+	registry := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("people.load.people.stored", registry).Inc(3)
+	metrics.GetOrRegisterGauge("people.dataset.size", registry).Update(2)
+	timer := metrics.GetOrRegisterTimer("GET /transformers/people", registry)
+	for i := 0; i < 2000; i++ {
+		timer.Update(2 * time.Second)
+	}
+
+	prometheusRegistry := prometheus.NewRegistry()
+	assert.NoError(t, prometheusRegistry.Register(NewMetricsCollector(registry)))
+	families, err := prometheusRegistry.Gather()
+	assert.NoError(t, err)
+
+	collected := map[string]float64{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			name := family.GetName()
+			for _, label := range metric.GetLabel() {
+				name += "{" + label.GetName() + "=" + label.GetValue() + "}"
+			}
+			switch {
+			case metric.GetCounter() != nil:
+				collected[name] = metric.GetCounter().GetValue()
+			case metric.GetGauge() != nil:
+				collected[name] = metric.GetGauge().GetValue()
+			default:
+				t.Errorf("%v is neither a counter nor a gauge", name)
+			}
+		}
+	}
+	assert.Equal(t, map[string]float64{
+		"people_load_people_stored_total":                3,
+		"people_dataset_size":                            2,
+		"GET_transformers_people_seconds_count":          2000,
+		"GET_transformers_people_seconds{quantile=0.5}":  2,
+		"GET_transformers_people_seconds{quantile=0.95}": 2,
+		"GET_transformers_people_seconds{quantile=0.99}": 2,
+	}, collected, "The count of a timer is of all its values, more than the 1028 sampled")
+}
@@ -0,0 +1,205 @@
+package people
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/pborman/uuid"
+)
+
+const (
+	publishBatchSize    = 100
+	conceptMessageType  = "concept-published"
+	deletedMessageType  = "concept-deleted"
+	tmeOriginSystemID   = "http://cmdb.ft.com/systems/tme"
+	kafkaProxyMediaType = "application/vnd.kafka.binary.v1+json"
+	messageTimeFormat   = "2006-01-02T15:04:05.000Z"
+)
+
+type httpClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+//Message is an FT message as read from the queue, headers first then the body
+type Message struct {
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+//MessageSink receives the messages published for the people added, changed or removed by a load
+type MessageSink interface {
+	Send(messages []Message) error
+}
+
+//conceptMessage is the UPP concept publishing body, removed people have no payload
+type conceptMessage struct {
+	ContentURI   string          `json:"contentUri"`
+	ChangeType   changeType      `json:"changeType"`
+	Payload      json.RawMessage `json:"payload"`
+	LastModified string          `json:"lastModified"`
+}
+
+//WithChangePublisher sends a message per person added, changed or removed by a load to the sink
+func WithChangePublisher(sink MessageSink) ServiceOption {
+	return func(s *peopleServiceImpl) {
+		s.loadListeners = append(s.loadListeners, &changePublisher{sink: sink, baseURL: s.baseURL})
+	}
+}
+
+type changePublisher struct {
+	sink    MessageSink
+	baseURL string
+}
+
+func (p *changePublisher) loadFinished(load loadResult) {
+	if load.err != nil {
+		log.Warnf("Not publishing the people changed by the failed load %v: %v", load.transactionID, load.err.Error())
+		return
+	}
+	base, err := linksBaseURL(p.baseURL, forwardedHeaders{})
+	if err != nil {
+		log.Errorf("ERROR publishing people: %v", err.Error())
+		return
+	}
+
+	published := 0
+	for start := 0; start < len(load.changes); start += publishBatchSize {
+		end := start + publishBatchSize
+		if end > len(load.changes) {
+			end = len(load.changes)
+		}
+		messages := make([]Message, 0, end-start)
+		for _, change := range load.changes[start:end] {
+			messages = append(messages, newConceptMessage(buildAPIURL(base, change.uuid), change, load.transactionID))
+		}
+		if err := p.sink.Send(messages); err != nil {
+			event(load.logger(), "publish_failed").WithFields(log.Fields{"published": published, "count": len(load.changes)}).WithError(err).Errorf("ERROR publishing people changed by load %v, %v of %v published: %v", load.transactionID, published, len(load.changes), err.Error())
+			return
+		}
+		published += len(messages)
+	}
+	event(load.logger(), "changes_published").WithFields(log.Fields{
+		"added":    load.countOf(personAdded),
+		"changed":  load.countOf(personChanged),
+		"removed":  load.countOf(personRemoved),
+		"migrated": load.countOf(personMigrated),
+	}).Infof("Published %v added, %v changed, %v removed and %v migrated people for load %v.", load.countOf(personAdded), load.countOf(personChanged), load.countOf(personRemoved), load.countOf(personMigrated), load.transactionID)
+}
+
+//newConceptMessage tells the removed people apart by their message type, as they have no payload
+func newConceptMessage(contentURI string, change personChange, transactionID string) Message {
+	now := time.Now().UTC().Format(messageTimeFormat)
+	body, _ := json.Marshal(conceptMessage{
+		ContentURI:   contentURI,
+		ChangeType:   change.change,
+		Payload:      json.RawMessage(change.marshalledPerson),
+		LastModified: now,
+	})
+	messageType := conceptMessageType
+	if change.change == personRemoved {
+		messageType = deletedMessageType
+	}
+	return Message{
+		Headers: map[string]string{
+			"Message-Id":        uuid.NewRandom().String(),
+			"Message-Timestamp": now,
+			"Message-Type":      messageType,
+			"Origin-System-Id":  tmeOriginSystemID,
+			"Content-Type":      "application/json",
+			"X-Request-Id":      transactionID,
+		},
+		Body: string(body),
+	}
+}
+
+//Build gives the message as written to the queue
+func (m Message) Build() string {
+	var names []string
+	for name := range m.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b bytes.Buffer
+	b.WriteString("FTMSG/1.0\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s: %s\n", name, m.Headers[name])
+	}
+	b.WriteString("\n")
+	b.WriteString(m.Body)
+	return b.String()
+}
+
+type kafkaProxySink struct {
+	client httpClient
+	url    string
+}
+
+type kafkaProxyRecords struct {
+	Records []kafkaProxyRecord `json:"records"`
+}
+
+type kafkaProxyRecord struct {
+	Value string `json:"value"`
+}
+
+//NewKafkaProxySink produces the messages to a topic through a Kafka REST proxy
+func NewKafkaProxySink(client httpClient, proxyAddress string, topic string) MessageSink {
+	return &kafkaProxySink{client: client, url: strings.TrimSuffix(proxyAddress, "/") + "/topics/" + topic}
+}
+
+func (k *kafkaProxySink) Send(messages []Message) error {
+	records := kafkaProxyRecords{Records: make([]kafkaProxyRecord, len(messages))}
+	for i, m := range messages {
+		records.Records[i].Value = base64.StdEncoding.EncodeToString([]byte(m.Build()))
+	}
+	body, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", k.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", kafkaProxyMediaType)
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Kafka proxy returned status %v for %v", resp.StatusCode, k.url)
+	}
+	return nil
+}
+
+type writerSink struct {
+	sync.Mutex
+	encoder *json.Encoder
+}
+
+//NewWriterSink writes the messages as json lines, e.g. to stdout or a file when testing
+func NewWriterSink(w io.Writer) MessageSink {
+	return &writerSink{encoder: json.NewEncoder(w)}
+}
+
+func (w *writerSink) Send(messages []Message) error {
+	w.Lock()
+	defer w.Unlock()
+	for _, m := range messages {
+		if err := w.encoder.Encode(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package people
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangePublisher(t *testing.T) {
+	var out bytes.Buffer
+	publisher := &changePublisher{sink: NewWriterSink(&out), baseURL: "http://localhost:8080/transformers/people/"}
+	publisher.loadFinished(loadResult{
+		transactionID: "tid_test",
+		changes: []personChange{
+			{uuid: testUUID, change: personAdded, marshalledPerson: []byte(`{"uuid":"` + testUUID + `"}`)},
+			{uuid: testUUID2, change: personRemoved},
+		},
+	})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 2)
+	var added, removed Message
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &added))
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &removed))
+
+	assert.Equal(t, "tid_test", added.Headers["X-Request-Id"])
+	assert.Equal(t, "concept-published", added.Headers["Message-Type"])
+	assert.Equal(t, "http://cmdb.ft.com/systems/tme", added.Headers["Origin-System-Id"])
+	assert.Len(t, added.Headers["Message-Id"], 36)
+	assert.NotEqual(t, added.Headers["Message-Id"], removed.Headers["Message-Id"])
+
+	var body conceptMessage
+	assert.NoError(t, json.Unmarshal([]byte(added.Body), &body))
+	assert.Equal(t, "http://localhost:8080/transformers/people/"+testUUID, body.ContentURI)
+	assert.JSONEq(t, `{"uuid":"`+testUUID+`"}`, string(body.Payload))
+	assert.Equal(t, personAdded, body.ChangeType)
+
+	assert.Equal(t, "concept-deleted", removed.Headers["Message-Type"])
+	assert.Equal(t, "tid_test", removed.Headers["X-Request-Id"])
+	assert.NoError(t, json.Unmarshal([]byte(removed.Body), &body))
+	assert.Equal(t, "http://localhost:8080/transformers/people/"+testUUID2, body.ContentURI)
+	assert.Equal(t, personRemoved, body.ChangeType)
+	assert.Contains(t, removed.Body, `"payload":null`)
+}
+
+func TestChangePublisherSkipsFailedLoads(t *testing.T) {
+	var out bytes.Buffer
+	publisher := &changePublisher{sink: NewWriterSink(&out), baseURL: "/base/url"}
+	publisher.loadFinished(loadResult{err: errors.New("TME is down")})
+	assert.Empty(t, out.String())
+}
+
+func TestBuildMessage(t *testing.T) {
+	m := Message{Headers: map[string]string{"X-Request-Id": "tid_test", "Message-Type": "concept-published"}, Body: `{"a":1}`}
+	assert.Equal(t, "FTMSG/1.0\nMessage-Type: concept-published\nX-Request-Id: tid_test\n\n{\"a\":1}", m.Build())
+}
+
+func TestKafkaProxySink(t *testing.T) {
+	var received kafkaProxyRecords
+	status := http.StatusOK
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "POST", req.Method)
+		assert.Equal(t, "/topics/Concept", req.URL.Path)
+		assert.Equal(t, "application/vnd.kafka.binary.v1+json", req.Header.Get("Content-Type"))
+		body, err := ioutil.ReadAll(req.Body)
+		assert.NoError(t, err)
+		assert.NoError(t, json.Unmarshal(body, &received))
+		w.WriteHeader(status)
+	}))
+	defer proxy.Close()
+
+	sink := NewKafkaProxySink(http.DefaultClient, proxy.URL+"/", "Concept")
+	m := Message{Headers: map[string]string{"X-Request-Id": "tid_test"}, Body: "{}"}
+	assert.NoError(t, sink.Send([]Message{m, m}))
+	assert.Len(t, received.Records, 2)
+	value, err := base64.StdEncoding.DecodeString(received.Records[0].Value)
+	assert.NoError(t, err)
+	assert.Equal(t, m.Build(), string(value))
+
+	status = http.StatusInternalServerError
+	assert.Error(t, sink.Send([]Message{m}))
+}
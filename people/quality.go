@@ -0,0 +1,186 @@
+package people
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/boltdb/bolt"
+)
+
+const mostAliasesReported = 10
+
+//suspiciousCharacters are seldom in the name of a person, and usually left over from markup or bad encodings
+const suspiciousCharacters = "<>{}[]|\\@#$%^*_=~\"�"
+
+//qualityReport describes the quality of the people served, computed once per load
+type qualityReport struct {
+	Generation           int                `json:"-"`
+	Count                int                `json:"count"`
+	MissingFields        map[string]int     `json:"missingFields"`
+	DuplicatePrefLabels  []duplicateLabel   `json:"duplicatePrefLabels"`
+	AliasCounts          map[string]int     `json:"aliasCounts"`
+	MostAliases          []aliasedPerson    `json:"mostAliases"`
+	NonLatinNames        []labelledPerson   `json:"nonLatinNames"`
+	SuspiciousCharacters []suspiciousPerson `json:"suspiciousCharacters"`
+}
+
+type duplicateLabel struct {
+	PrefLabel string   `json:"prefLabel"`
+	UUIDs     []string `json:"uuids"`
+}
+
+type labelledPerson struct {
+	UUID      string `json:"uuid"`
+	PrefLabel string `json:"prefLabel"`
+}
+
+type aliasedPerson struct {
+	UUID      string `json:"uuid"`
+	PrefLabel string `json:"prefLabel"`
+	Aliases   int    `json:"aliases"`
+}
+
+type suspiciousPerson struct {
+	UUID       string `json:"uuid"`
+	PrefLabel  string `json:"prefLabel"`
+	Characters string `json:"characters"`
+}
+
+//aliasCountRanges bucket the number of aliases of each person, by the highest count in the range
+var aliasCountRanges = []struct {
+	name string
+	max  int
+}{{"0", 0}, {"1", 1}, {"2-5", 5}, {"6-10", 10}, {"11-50", 50}, {"51+", int(^uint(0) >> 1)}}
+
+func newQualityReport() qualityReport {
+	report := qualityReport{
+		MissingFields:        map[string]int{"prefLabel": 0, "name": 0, "aliases": 0, "alternativeIdentifiers.TME": 0},
+		DuplicatePrefLabels:  []duplicateLabel{},
+		AliasCounts:          map[string]int{},
+		MostAliases:          []aliasedPerson{},
+		NonLatinNames:        []labelledPerson{},
+		SuspiciousCharacters: []suspiciousPerson{},
+	}
+	for _, r := range aliasCountRanges {
+		report.AliasCounts[r.name] = 0
+	}
+	return report
+}
+
+//buildQualityReport goes through the people once, in the order they are stored
+func buildQualityReport(people []person) qualityReport {
+	report := newQualityReport()
+	byLabel := map[string][]string{}
+	for _, p := range people {
+		report.Count++
+		label := strings.TrimSpace(p.PrefLabel)
+		if label == "" {
+			report.MissingFields["prefLabel"]++
+		} else {
+			byLabel[label] = append(byLabel[label], p.UUID)
+		}
+		if strings.TrimSpace(p.Name) == "" {
+			report.MissingFields["name"]++
+		}
+		if len(p.Aliases) == 0 {
+			report.MissingFields["aliases"]++
+		}
+		if len(p.AlternativeIdentifiers.TME) == 0 {
+			report.MissingFields["alternativeIdentifiers.TME"]++
+		}
+		for _, r := range aliasCountRanges {
+			if len(p.Aliases) <= r.max {
+				report.AliasCounts[r.name]++
+				break
+			}
+		}
+		report.MostAliases = append(report.MostAliases, aliasedPerson{UUID: p.UUID, PrefLabel: p.PrefLabel, Aliases: len(p.Aliases)})
+		if !isLatin(p.PrefLabel) {
+			report.NonLatinNames = append(report.NonLatinNames, labelledPerson{UUID: p.UUID, PrefLabel: p.PrefLabel})
+		}
+		if found := findSuspiciousCharacters(p.PrefLabel); found != "" {
+			report.SuspiciousCharacters = append(report.SuspiciousCharacters, suspiciousPerson{UUID: p.UUID, PrefLabel: p.PrefLabel, Characters: found})
+		}
+	}
+
+	for label, uuids := range byLabel {
+		if len(uuids) > 1 {
+			report.DuplicatePrefLabels = append(report.DuplicatePrefLabels, duplicateLabel{PrefLabel: label, UUIDs: uuids})
+		}
+	}
+	sort.Slice(report.DuplicatePrefLabels, func(i, j int) bool {
+		return report.DuplicatePrefLabels[i].PrefLabel < report.DuplicatePrefLabels[j].PrefLabel
+	})
+	sort.SliceStable(report.MostAliases, func(i, j int) bool {
+		return report.MostAliases[i].Aliases > report.MostAliases[j].Aliases
+	})
+	if len(report.MostAliases) > mostAliasesReported {
+		report.MostAliases = report.MostAliases[:mostAliasesReported]
+	}
+	return report
+}
+
+//isLatin tells whether all the letters of the name are in the Latin script
+func isLatin(name string) bool {
+	for _, r := range name {
+		if unicode.IsLetter(r) && !unicode.Is(unicode.Latin, r) {
+			return false
+		}
+	}
+	return true
+}
+
+//findSuspiciousCharacters lists the suspicious and control characters of the name, each once
+func findSuspiciousCharacters(name string) string {
+	var found []rune
+	for _, r := range name {
+		if (strings.ContainsRune(suspiciousCharacters, r) || unicode.IsControl(r)) && !strings.ContainsRune(string(found), r) {
+			found = append(found, r)
+		}
+	}
+	return string(found)
+}
+
+//getQualityReport computes the report of the people served once per generation, as soon as it is loaded or else when asked for
+func (s *peopleServiceImpl) getQualityReport() (qualityReport, error) {
+	s.RLock()
+	generation := s.dataset.generation
+	if s.quality != nil && s.quality.Generation == generation {
+		defer s.RUnlock()
+		return *s.quality, nil
+	}
+	people, err := s.servedPeople()
+	s.RUnlock()
+	if err != nil {
+		return qualityReport{}, err
+	}
+
+	report := buildQualityReport(people)
+	report.Generation = generation
+	s.Lock()
+	s.quality = &report
+	s.Unlock()
+	return report, nil
+}
+
+//servedPeople reads the people served in the order they are stored, it must be called holding the lock
+func (s *peopleServiceImpl) servedPeople() ([]person, error) {
+	var people []person
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(s.served().people))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var p person
+			if err := json.Unmarshal(v, &p); err != nil {
+				return err
+			}
+			people = append(people, p)
+			return nil
+		})
+	})
+	return people, err
+}
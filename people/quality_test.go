@@ -0,0 +1,87 @@
+package people
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsLatin(t *testing.T) {
+	tests := []struct {
+		name  string
+		latin bool
+	}{
+		{"Bob Smith", true},
+		{"Zoë Ñúñez-O'Brien", true},
+		{"Dr. J. R. Smith 3rd", true},
+		{"Владимир Путин", false},
+		{"习近平", false},
+		{"Bob Σmith", false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.latin, isLatin(test.name), test.name)
+	}
+}
+
+func TestFindSuspiciousCharacters(t *testing.T) {
+	tests := []struct {
+		name  string
+		found string
+	}{
+		{"Bob Smith", ""},
+		{"Zoë O'Brien-Smith", ""},
+		{"<b>Bob</b>", "<>"},
+		{"Bob_Smith__", "_"},
+		{"Bob\tSmith", "\t"},
+		{"Bob Sm�th", "�"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.found, findSuspiciousCharacters(test.name), test.name)
+	}
+}
+
+func TestBuildQualityReport(t *testing.T) {
+	manyAliases := make([]string, 12)
+	report := buildQualityReport([]person{
+		{UUID: "1", PrefLabel: "Bob Smith", Aliases: []string{"Bob Smith"}, AlternativeIdentifiers: alternativeIdentifiers{TME: []string{"bob"}}},
+		{UUID: "2", PrefLabel: "Bob Smith", Aliases: []string{"Bob Smith", "Robert Smith"}, AlternativeIdentifiers: alternativeIdentifiers{TME: []string{"robert"}}},
+		{UUID: "3", PrefLabel: " ", AlternativeIdentifiers: alternativeIdentifiers{TME: []string{"blank"}}},
+		{UUID: "4", PrefLabel: "Владимир Путин", Aliases: manyAliases},
+		{UUID: "5", PrefLabel: "<b>Fred</b>", Aliases: []string{"Fred"}, AlternativeIdentifiers: alternativeIdentifiers{TME: []string{"fred"}}},
+	})
+
+	assert.Equal(t, 5, report.Count)
+	assert.Equal(t, map[string]int{"prefLabel": 1, "name": 5, "aliases": 1, "alternativeIdentifiers.TME": 1}, report.MissingFields)
+	assert.Equal(t, []duplicateLabel{{PrefLabel: "Bob Smith", UUIDs: []string{"1", "2"}}}, report.DuplicatePrefLabels)
+	assert.Equal(t, map[string]int{"0": 1, "1": 2, "2-5": 1, "6-10": 0, "11-50": 1, "51+": 0}, report.AliasCounts)
+	assert.Equal(t, aliasedPerson{UUID: "4", PrefLabel: "Владимир Путин", Aliases: 12}, report.MostAliases[0])
+	assert.Len(t, report.MostAliases, 5)
+	assert.Equal(t, []labelledPerson{{UUID: "4", PrefLabel: "Владимир Путин"}}, report.NonLatinNames)
+	assert.Equal(t, []suspiciousPerson{{UUID: "5", PrefLabel: "<b>Fred</b>", Characters: "<>"}}, report.SuspiciousCharacters)
+}
+
+func TestQualityReportIsRefreshedAfterEachLoad(t *testing.T) {
+	tmpfile := getTempFile(t)
+	defer os.Remove(tmpfile.Name())
+	repo := dummyRepo{terms: []term{{CanonicalName: "Bob", RawID: "bob"}, {CanonicalName: "Fred", RawID: "fred"}}}
+	listener := &recordingListener{}
+	service := NewPeopleService(&repo, "/base/url", "taxonomy_string", 1, tmpfile.Name(), withLoadListener(listener))
+	defer service.Shutdown()
+	assert.NoError(t, listener.waitForLoads(t, 1).err)
+
+	report, err := service.getQualityReport()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Count)
+	assert.Empty(t, report.DuplicatePrefLabels)
+
+	repo.terms = []term{{CanonicalName: "Bob", RawID: "bob"}, {CanonicalName: "Bob", RawID: "bob2"}, {CanonicalName: "Fred", RawID: "fred"}}
+	repo.count = 0
+	assert.NoError(t, service.reloadDB(context.Background()))
+	report, err = service.getQualityReport()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, report.Count)
+	assert.Len(t, report.DuplicatePrefLabels, 1)
+	assert.Equal(t, "Bob", report.DuplicatePrefLabels[0].PrefLabel)
+}
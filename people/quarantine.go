@@ -0,0 +1,85 @@
+package people
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/boltdb/bolt"
+)
+
+//quarantinedTerm is a TME term, or the person it was transformed into, left out of a load with the reason why
+type quarantinedTerm struct {
+	ID     string      `json:"id,omitempty"`
+	Reason string      `json:"reason"`
+	Term   interface{} `json:"term"`
+}
+
+//transformedBatch is a page of terms as the people transformed from the valid ones and the quarantined rest
+type transformedBatch struct {
+	people      []person
+	quarantined []quarantinedTerm
+}
+
+//validateTerm checks a term has what a person is built from
+func validateTerm(iTerm interface{}) (term, error) {
+	t, isTerm := iTerm.(term)
+	if !isTerm {
+		return term{}, fmt.Errorf("Not a TME term but a %T", iTerm)
+	}
+	if strings.TrimSpace(t.RawID) == "" {
+		return term{}, errors.New("Term has no id")
+	}
+	if strings.TrimSpace(t.CanonicalName) == "" {
+		return term{}, errors.New("Term has no name")
+	}
+	return t, nil
+}
+
+//quarantineTerm keeps the raw term, as json when it is a term and as text otherwise
+func quarantineTerm(iTerm interface{}, reason error) quarantinedTerm {
+	quarantined := quarantinedTerm{Reason: reason.Error(), Term: fmt.Sprintf("%v", iTerm)}
+	if t, isTerm := iTerm.(term); isTerm {
+		quarantined.ID = t.RawID
+		quarantined.Term = t
+	}
+	return quarantined
+}
+
+func putQuarantined(bucket *bolt.Bucket, quarantined quarantinedTerm) error {
+	marshalled, err := json.Marshal(quarantined)
+	if err != nil {
+		return err
+	}
+	sequence, err := bucket.NextSequence()
+	if err != nil {
+		return err
+	}
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, sequence)
+	return bucket.Put(key, marshalled)
+}
+
+//getQuarantine lists the terms quarantined by the load served, in the order they were met
+func (s *peopleServiceImpl) getQuarantine() ([]quarantinedTerm, error) {
+	s.RLock()
+	defer s.RUnlock()
+	quarantine := []quarantinedTerm{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(s.served().quarantine))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var quarantined quarantinedTerm
+			if err := json.Unmarshal(v, &quarantined); err != nil {
+				return err
+			}
+			quarantine = append(quarantine, quarantined)
+			return nil
+		})
+	})
+	return quarantine, err
+}
@@ -0,0 +1,70 @@
+package people
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//pagesRepo serves its pages of terms as they are, valid or not
+type pagesRepo struct {
+	pages [][]interface{}
+}
+
+func (r *pagesRepo) GetTmeTermsFromIndex(startRecord int) ([]interface{}, error) {
+	if startRecord >= len(r.pages) {
+		return nil, nil
+	}
+	return r.pages[startRecord], nil
+}
+
+func (r *pagesRepo) GetTmeTermById(uuid string) (interface{}, error) {
+	return nil, nil
+}
+
+func TestValidateTerm(t *testing.T) {
+	tests := []struct {
+		name   string
+		term   interface{}
+		reason error
+	}{
+		{"Valid", term{CanonicalName: "Bob", RawID: "bob"}, nil},
+		{"No id", term{CanonicalName: "Bob", RawID: " "}, errors.New("Term has no id")},
+		{"No name", term{RawID: "bob"}, errors.New("Term has no name")},
+		{"Not a term", "bob", errors.New("Not a TME term but a string")},
+	}
+	for _, test := range tests {
+		_, err := validateTerm(test.term)
+		assert.Equal(t, test.reason, err, test.name)
+	}
+}
+
+func TestInvalidTermsAreQuarantined(t *testing.T) {
+	tmpfile := getTempFile(t)
+	defer os.Remove(tmpfile.Name())
+	repo := pagesRepo{pages: [][]interface{}{
+		{term{CanonicalName: "Bob", RawID: "bob"}, term{RawID: "nameless"}},
+		{"not a term", term{CanonicalName: "Fred", RawID: "fred"}},
+	}}
+	listener := &recordingListener{}
+	service := NewPeopleService(&repo, "/base/url", "taxonomy_string", 1, tmpfile.Name(), withLoadListener(listener))
+	defer service.Shutdown()
+	assert.NoError(t, listener.waitForLoads(t, 1).err)
+
+	assertCount(t, service, 2)
+	quarantine, err := service.getQuarantine()
+	assert.NoError(t, err)
+	assert.Equal(t, []quarantinedTerm{
+		{ID: "nameless", Reason: "Term has no name", Term: map[string]interface{}{"CanonicalName": "", "RawID": "nameless", "Aliases": map[string]interface{}{"Alias": nil}}},
+		{Reason: "Not a TME term but a string", Term: "not a term"},
+	}, quarantine)
+
+	repo.pages = [][]interface{}{{term{CanonicalName: "Bob", RawID: "bob"}}}
+	assert.NoError(t, service.reloadDB(context.Background()))
+	quarantine, err = service.getQuarantine()
+	assert.NoError(t, err)
+	assert.Empty(t, quarantine, "Only the terms quarantined by the load served are reported")
+}
@@ -0,0 +1,133 @@
+package people
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	rdfType       = "http://www.w3.org/1999/02/22-rdf-syntax-ns#type"
+	skosNS        = "http://www.w3.org/2004/02/skos/core#"
+	skosPrefLabel = skosNS + "prefLabel"
+	skosAltLabel  = skosNS + "altLabel"
+	skosNotation  = skosNS + "notation"
+)
+
+const turtlePrefixes = "@prefix rdf: <http://www.w3.org/1999/02/22-rdf-syntax-ns#> .\n" +
+	"@prefix skos: <" + skosNS + "> .\n\n"
+
+//triple is a statement about a person, with either an IRI or a literal object. Identifiers are literals typed with their authority
+type triple struct {
+	predicate string
+	object    string
+	iri       bool
+	datatype  string
+}
+
+func personTriples(p person) []triple {
+	var triples []triple
+	for _, t := range personTypes {
+		triples = append(triples, triple{predicate: rdfType, object: t, iri: true})
+	}
+	triples = append(triples, triple{predicate: skosPrefLabel, object: p.PrefLabel})
+	for _, a := range p.Aliases {
+		triples = append(triples, triple{predicate: skosAltLabel, object: a})
+	}
+	for _, uuid := range p.AlternativeIdentifiers.Uuids {
+		triples = append(triples, triple{predicate: skosNotation, object: uuid, datatype: uppAuthority})
+	}
+	for _, tmeIdentifier := range p.AlternativeIdentifiers.TME {
+		triples = append(triples, triple{predicate: skosNotation, object: tmeIdentifier, datatype: tmeAuthority})
+	}
+	return triples
+}
+
+func (t triple) objectTerm() string {
+	if t.iri {
+		return iriTerm(t.object)
+	}
+	literal := "\"" + escapeLiteral(t.object) + "\""
+	if t.datatype != "" {
+		literal += "^^" + iriTerm(t.datatype)
+	}
+	return literal
+}
+
+//renderNTriples writes one line per statement, so the output can be split and loaded in parallel
+func renderNTriples(w io.Writer, p person) error {
+	subject := iriTerm(thingURI(p.UUID))
+	for _, t := range personTriples(p) {
+		if _, err := fmt.Fprintf(w, "%s %s %s .\n", subject, iriTerm(t.predicate), t.objectTerm()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//renderTurtle writes a person as a single block of statements sharing the subject
+func renderTurtle(w io.Writer, p person) error {
+	var b bytes.Buffer
+	b.WriteString(iriTerm(thingURI(p.UUID)))
+	previous := ""
+	for _, t := range personTriples(p) {
+		if t.predicate == previous {
+			b.WriteString(", ")
+		} else {
+			if previous != "" {
+				b.WriteString(" ;")
+			}
+			b.WriteString("\n\t")
+			b.WriteString(turtlePredicate(t.predicate))
+			b.WriteString(" ")
+		}
+		b.WriteString(t.objectTerm())
+		previous = t.predicate
+	}
+	b.WriteString(" .\n\n")
+	_, err := b.WriteTo(w)
+	return err
+}
+
+func turtlePredicate(predicate string) string {
+	if predicate == rdfType {
+		return "a"
+	}
+	if strings.HasPrefix(predicate, skosNS) {
+		return "skos:" + strings.TrimPrefix(predicate, skosNS)
+	}
+	return iriTerm(predicate)
+}
+
+func iriTerm(iri string) string {
+	return "<" + iri + ">"
+}
+
+var literalEscaper = strings.NewReplacer(
+	"\\", "\\\\",
+	"\"", "\\\"",
+	"\n", "\\n",
+	"\r", "\\r",
+	"\t", "\\t",
+)
+
+func escapeLiteral(value string) string {
+	escaped := literalEscaper.Replace(value)
+	if strings.IndexFunc(escaped, isControl) < 0 {
+		return escaped
+	}
+	var b bytes.Buffer
+	for _, r := range escaped {
+		if isControl(r) {
+			fmt.Fprintf(&b, "\\u%04X", r)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func isControl(r rune) bool {
+	return r < 0x20 || r == 0x7F
+}
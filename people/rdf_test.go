@@ -0,0 +1,89 @@
+package people
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var rdfTestPerson = person{
+	UUID:      testUUID,
+	PrefLabel: "Bob \"The Builder\"",
+	Aliases:   []string{"Robert\nSmith"},
+	AlternativeIdentifiers: alternativeIdentifiers{
+		TME:   []string{"Ym9i-UE4="},
+		Uuids: []string{testUUID},
+	},
+}
+
+func TestRenderNTriples(t *testing.T) {
+	var b bytes.Buffer
+	assert.NoError(t, renderNTriples(&b, rdfTestPerson))
+	assert.Equal(t, `<http://api.ft.com/things/bba39990-c78d-3629-ae83-808c333c6dbc> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://www.ft.com/ontology/thing/Thing> .
+<http://api.ft.com/things/bba39990-c78d-3629-ae83-808c333c6dbc> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://www.ft.com/ontology/concept/Concept> .
+<http://api.ft.com/things/bba39990-c78d-3629-ae83-808c333c6dbc> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://www.ft.com/ontology/person/Person> .
+<http://api.ft.com/things/bba39990-c78d-3629-ae83-808c333c6dbc> <http://www.w3.org/2004/02/skos/core#prefLabel> "Bob \"The Builder\"" .
+<http://api.ft.com/things/bba39990-c78d-3629-ae83-808c333c6dbc> <http://www.w3.org/2004/02/skos/core#altLabel> "Robert\nSmith" .
+<http://api.ft.com/things/bba39990-c78d-3629-ae83-808c333c6dbc> <http://www.w3.org/2004/02/skos/core#notation> "bba39990-c78d-3629-ae83-808c333c6dbc"^^<http://api.ft.com/system/FT-UPP> .
+<http://api.ft.com/things/bba39990-c78d-3629-ae83-808c333c6dbc> <http://www.w3.org/2004/02/skos/core#notation> "Ym9i-UE4="^^<http://api.ft.com/system/FT-TME> .
+`, b.String())
+}
+
+func TestRenderTurtle(t *testing.T) {
+	var b bytes.Buffer
+	assert.NoError(t, renderTurtle(&b, rdfTestPerson))
+	assert.Equal(t, `<http://api.ft.com/things/bba39990-c78d-3629-ae83-808c333c6dbc>
+	a <http://www.ft.com/ontology/thing/Thing>, <http://www.ft.com/ontology/concept/Concept>, <http://www.ft.com/ontology/person/Person> ;
+	skos:prefLabel "Bob \"The Builder\"" ;
+	skos:altLabel "Robert\nSmith" ;
+	skos:notation "bba39990-c78d-3629-ae83-808c333c6dbc"^^<http://api.ft.com/system/FT-UPP>, "Ym9i-UE4="^^<http://api.ft.com/system/FT-TME> .
+
+`, b.String())
+}
+
+func TestEscapeLiteral(t *testing.T) {
+	assert.Equal(t, `a\\b`, escapeLiteral(`a\b`))
+	assert.Equal(t, `tab\there`, escapeLiteral("tab\there"))
+	assert.Equal(t, `bell\u0007`, escapeLiteral("bell\a"))
+	assert.Equal(t, "Zoë", escapeLiteral("Zoë"))
+}
+
+func TestExport(t *testing.T) {
+	s := &dummyService{
+		initialised: true,
+		count:       1,
+		people:      []person{rdfTestPerson}}
+
+	rec := httptest.NewRecorder()
+	router(s).ServeHTTP(rec, newRequest("GET", "/transformers/people/__export?format=turtle"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/turtle; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(rec.Body.String(), turtlePrefixes+"<http://api.ft.com/things/"+testUUID+">"))
+
+	rec = httptest.NewRecorder()
+	router(s).ServeHTTP(rec, newRequest("GET", "/transformers/people/__export?format=ntriples"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/n-triples; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, 7, strings.Count(rec.Body.String(), "\n"))
+
+	rec = httptest.NewRecorder()
+	router(s).ServeHTTP(rec, newRequest("GET", "/transformers/people/__export?format=rdfxml"))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "{\"message\": \"Unsupported export format [rdfxml], expected one of: csv, ntriples, turtle\"}\n", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	router(s).ServeHTTP(rec, newRequest("POST", "/transformers/people/__export?format=turtle"))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router(&dummyService{initialised: true}).ServeHTTP(rec, newRequest("GET", "/transformers/people/__export?format=turtle"))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router(&dummyService{}).ServeHTTP(rec, newRequest("GET", "/transformers/people/__export?format=turtle"))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
@@ -0,0 +1,103 @@
+package people
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+const (
+	mediaTypeJSON     = "application/json"
+	mediaTypePeopleV2 = "application/vnd.ft.people.v2+json"
+)
+
+//personRenderer writes a single person in a given representation
+type personRenderer func(w io.Writer, p person) error
+
+//representation is a JSON shape people can be served in. The default one is the stored json, which needs no conversion
+type representation struct {
+	mediaType string
+	variant   string
+	view      func(p person) interface{}
+}
+
+var (
+	peopleV1 = representation{mediaType: mediaTypeJSON}
+	peopleV2 = representation{mediaType: mediaTypePeopleV2, variant: "v2", view: func(p person) interface{} { return newPersonV2(p) }}
+)
+
+//alternativeRepresentations are only served when explicitly asked for in the Accept header
+var alternativeRepresentations = []representation{peopleV2, peopleJSONLD}
+
+//negotiateRepresentation picks the representation from the Accept header, keeping the current shape as the default
+func negotiateRepresentation(writer http.ResponseWriter, req *http.Request) representation {
+	writer.Header().Add("Vary", "Accept")
+	chosen := peopleV1
+	accept := req.Header.Get("Accept")
+	if accept != "" {
+		weights := parseQualityValues(accept)
+		chosenWeight := defaultRepresentationWeight(weights)
+		for _, r := range alternativeRepresentations {
+			weight, listed := weights[r.mediaType]
+			if !listed || weight <= 0 {
+				continue
+			}
+			if weight > chosenWeight || (weight == chosenWeight && chosen.isDefault()) {
+				chosen, chosenWeight = r, weight
+			}
+		}
+	}
+	writer.Header().Set("Content-Type", chosen.mediaType)
+	return chosen
+}
+
+func defaultRepresentationWeight(weights map[string]float64) float64 {
+	if weight, listed := weights[mediaTypeJSON]; listed {
+		return weight
+	}
+	weight := weights["*/*"]
+	if w := weights["application/*"]; w > weight {
+		weight = w
+	}
+	return weight
+}
+
+func (r representation) isDefault() bool {
+	return r.view == nil
+}
+
+func (r representation) of(p person) interface{} {
+	if r.isDefault() {
+		return p
+	}
+	return r.view(p)
+}
+
+//render streams people one json document per line, like the stored representation
+func (r representation) render(w io.Writer, p person) error {
+	return json.NewEncoder(w).Encode(r.of(p))
+}
+
+func newPersonV2(p person) personV2 {
+	v2 := personV2{
+		UUID:        p.UUID,
+		PrefLabel:   p.PrefLabel,
+		Type:        p.Type,
+		Identifiers: []identifier{},
+	}
+	for _, uuid := range p.AlternativeIdentifiers.Uuids {
+		v2.Identifiers = append(v2.Identifiers, identifier{Authority: uppAuthority, IdentifierValue: uuid})
+	}
+	for _, tmeIdentifier := range p.AlternativeIdentifiers.TME {
+		v2.Identifiers = append(v2.Identifiers, identifier{Authority: tmeAuthority, IdentifierValue: tmeIdentifier})
+		termID, taxonomyName, err := parseTmeIdentifier(tmeIdentifier)
+		if err != nil {
+			continue
+		}
+		v2.Provenance = append(v2.Provenance, provenance{Authority: tmeAuthority, Taxonomy: taxonomyName, TermID: termID})
+	}
+	for _, label := range p.Aliases {
+		v2.Aliases = append(v2.Aliases, structuredAlias{Label: label, Type: "variation"})
+	}
+	return v2
+}
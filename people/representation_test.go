@@ -0,0 +1,102 @@
+package people
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateRepresentation(t *testing.T) {
+	tests := []struct {
+		accept   string
+		expected representation
+	}{
+		{"", peopleV1},
+		{"*/*", peopleV1},
+		{"application/json", peopleV1},
+		{"text/html", peopleV1},
+		{"application/vnd.ft.people.v2+json", peopleV2},
+		{"application/vnd.ft.people.v2+json, application/json;q=0.5", peopleV2},
+		{"application/vnd.ft.people.v2+json;q=0.5, application/json", peopleV1},
+		{"application/vnd.ft.people.v2+json, */*", peopleV2},
+		{"application/vnd.ft.people.v2+json;q=0", peopleV1},
+		{"application/ld+json", peopleJSONLD},
+		{"application/ld+json;q=0.9, application/vnd.ft.people.v2+json", peopleV2},
+		{"application/ld+json, application/vnd.ft.people.v2+json", peopleV2},
+		{"application/ld+json, application/json", peopleJSONLD},
+	}
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		req := newRequest("GET", "/transformers/people")
+		req.Header.Set("Accept", test.accept)
+		repr := negotiateRepresentation(rec, req)
+		assert.Equal(t, test.expected.mediaType, repr.mediaType, test.accept)
+		assert.Equal(t, test.expected.mediaType, rec.Header().Get("Content-Type"), test.accept)
+		assert.Equal(t, "Accept", rec.Header().Get("Vary"), test.accept)
+	}
+}
+
+func TestNewPersonV2(t *testing.T) {
+	p := transformPerson(term{CanonicalName: "Bob", RawID: "bob", Aliases: aliases{Alias: []alias{{Name: "Robert"}}}}, taxonomyName, LegacyUUIDScheme)
+	v2 := newPersonV2(p)
+
+	assert.Equal(t, p.UUID, v2.UUID)
+	assert.Equal(t, "Bob", v2.PrefLabel)
+	assert.Equal(t, "Person", v2.Type)
+	assert.Equal(t, []identifier{
+		{Authority: uppAuthority, IdentifierValue: p.UUID},
+		{Authority: tmeAuthority, IdentifierValue: "Ym9i-dGF4b25vbXlfbmFtZQ=="},
+	}, v2.Identifiers)
+	assert.Equal(t, []structuredAlias{{Label: "Robert", Type: "variation"}}, v2.Aliases)
+	assert.Equal(t, []provenance{{Authority: tmeAuthority, Taxonomy: taxonomyName, TermID: "bob"}}, v2.Provenance)
+}
+
+func TestGetPersonByUUIDV2(t *testing.T) {
+	p := transformPerson(term{CanonicalName: "Bob", RawID: "bob"}, taxonomyName, LegacyUUIDScheme)
+	p.Hash = "abc"
+	s := &dummyService{
+		found:       true,
+		initialised: true,
+		people:      []person{p}}
+
+	rec := httptest.NewRecorder()
+	req := newRequest("GET", fmt.Sprintf("/transformers/people/%s", testUUID))
+	req.Header.Set("Accept", mediaTypePeopleV2)
+	router(s).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, mediaTypePeopleV2, rec.Header().Get("Content-Type"))
+	assert.Equal(t, `"abc-v2"`, rec.Header().Get("ETag"))
+	var v2 personV2
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &v2))
+	assert.Equal(t, newPersonV2(p), v2)
+
+	rec = httptest.NewRecorder()
+	router(s).ServeHTTP(rec, newRequest("GET", fmt.Sprintf("/transformers/people/%s", testUUID)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, mediaTypeJSON, rec.Header().Get("Content-Type"))
+	assert.Equal(t, `"abc"`, rec.Header().Get("ETag"))
+	assert.NotContains(t, rec.Body.String(), "identifiers")
+}
+
+func TestGetPeopleV2(t *testing.T) {
+	s := &dummyService{
+		initialised: true,
+		count:       2,
+		people:      []person{{UUID: testUUID, PrefLabel: "Bob"}, {UUID: testUUID2, PrefLabel: "Fred"}}}
+
+	rec := httptest.NewRecorder()
+	req := newRequest("GET", "/transformers/people")
+	req.Header.Set("Accept", mediaTypePeopleV2)
+	router(s).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, mediaTypePeopleV2, rec.Header().Get("Content-Type"))
+	assert.Equal(t, `{"uuid":"bba39990-c78d-3629-ae83-808c333c6dbc","prefLabel":"Bob","type":"","identifiers":[]}
+{"uuid":"be2e7e2b-0fa2-3969-a69b-74c46e754032","prefLabel":"Fred","type":"","identifiers":[]}
+`, rec.Body.String())
+}
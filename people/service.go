@@ -1,32 +1,59 @@
 package people
 
 import (
+	"context"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/Financial-Times/tme-reader/tmereader"
+	"github.com/Financial-Times/transactionid-utils-go"
 	log "github.com/Sirupsen/logrus"
 	"github.com/boltdb/bolt"
+	"github.com/pborman/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"io"
 	"sync"
 	"time"
 )
 
 const (
-	cacheBucket = "person"
-	//uppAuthority = "http://api.ft.com/system/FT-UPP"
-	//tmeAuthority = "http://api.ft.com/system/FT-TME"
+	cacheBucket      = "person"
+	hashBucket       = "personHash"
+	quarantineBucket = "personQuarantine"
+	aliasBucket      = "personAlias"
+	uppAuthority     = "http://api.ft.com/system/FT-UPP"
+	tmeAuthority     = "http://api.ft.com/system/FT-TME"
 )
 
+//errAlreadyLoading refuses a load or an import while another one is writing to the cache file
+var errAlreadyLoading = errors.New("People are already loading")
+
 type PeopleService interface {
 	getPeople() (io.PipeReader, error)
-	getPeopleLinks() (io.PipeReader, error)
+	getPeopleAs(render personRenderer) (io.PipeReader, error)
+	getPeopleLinks(fwd forwardedHeaders) (io.PipeReader, error)
 	getPeopleUUIDs() (io.PipeReader, error)
 	getPersonByUUID(uuid string) (person, bool, error)
+	exportSnapshot(w io.Writer) error
+	importSnapshot(r io.Reader) (int, error)
 	getCount() (int, error)
+	getDatasetVersion() datasetVersion
+	getDatasetDiff() (datasetDiff, bool, error)
+	getRejectedLoad() error
+	getQuarantine() ([]quarantinedTerm, error)
+	getQualityReport() (qualityReport, error)
+	getDuplicates() ([]duplicateCluster, error)
+	checkRepository() error
+	checkDataFreshness() (string, error)
+	checkCacheDB() error
 	isInitialised() bool
 	isDataLoaded() bool
-	reloadDB() error
+	isLoading() bool
+	checkDataAge() error
+	reloadDB(ctx context.Context) error
+	startReload(ctx context.Context) bool
 	Shutdown() error
 }
 
@@ -40,12 +67,47 @@ type peopleServiceImpl struct {
 	dataLoaded    bool
 	cacheFileName string
 	db            *bolt.DB
+	dataset       datasetVersion
+	cachedOnStart bool
+	loadListeners []loadListener
+	listeners     []*listenerQueue
+	current       int
+	loading       bool
+	discarded     bool
+	maxShrink     int
+	rejectedLoad  error
+	probe         Probe
+	maxDataAge    time.Duration
+	lastLoad      time.Time
+	quality       *qualityReport
+	duplicates    *duplicateClusters
+	canonical     map[string]bool
+	uuidScheme    UUIDScheme
+	migratedFrom  []UUIDScheme
 }
 
-func NewPeopleService(repo tmereader.Repository, baseURL string, taxonomyName string, maxTmeRecords int, cacheFileName string) PeopleService {
-	s := &peopleServiceImpl{repository: repo, baseURL: baseURL, taxonomyName: taxonomyName, maxTmeRecords: maxTmeRecords, initialised: true, cacheFileName: cacheFileName}
+func NewPeopleService(repo tmereader.Repository, baseURL string, taxonomyName string, maxTmeRecords int, cacheFileName string, options ...ServiceOption) PeopleService {
+	s := &peopleServiceImpl{repository: repo, baseURL: baseURL, taxonomyName: taxonomyName, maxTmeRecords: maxTmeRecords, initialised: true, cacheFileName: cacheFileName, uuidScheme: LegacyUUIDScheme}
+	for _, option := range options {
+		option(s)
+	}
+	for _, listener := range s.loadListeners {
+		s.listeners = append(s.listeners, &listenerQueue{listener: listener})
+	}
 	go func(service *peopleServiceImpl) {
-		err := service.loadDB()
+		if service.cachedOnStart {
+			loaded, err := service.loadCachedDB()
+			if err != nil {
+				log.Errorf("Error while creating PeopleService from cache: [%v]", err.Error())
+				service.setInitialised(false)
+				return
+			}
+			if loaded {
+				log.Info("Serving people from the cache file, they will be fetched from TME on the next reload.")
+				return
+			}
+		}
+		err := service.reloadDB(context.Background())
 		if err != nil {
 			log.Errorf("Error while creating PeopleService: [%v]", err.Error())
 		}
@@ -77,6 +139,58 @@ func (s *peopleServiceImpl) setDataLoaded(val bool) {
 	s.Unlock()
 }
 
+//startLoad claims the cache file for a load or an import, false when people are already loading
+func (s *peopleServiceImpl) startLoad() bool {
+	s.Lock()
+	defer s.Unlock()
+	if s.loading {
+		return false
+	}
+	s.loading = true
+	return true
+}
+
+func (s *peopleServiceImpl) setLoading(val bool) {
+	s.Lock()
+	s.loading = val
+	s.Unlock()
+}
+
+//isLoading tells whether people are being loaded, while the ones of the previous load are still served
+func (s *peopleServiceImpl) isLoading() bool {
+	s.RLock()
+	defer s.RUnlock()
+	return s.loading
+}
+
+func (s *peopleServiceImpl) getDatasetVersion() datasetVersion {
+	s.RLock()
+	defer s.RUnlock()
+	return s.dataset
+}
+
+//setDatasetDigest starts a new generation, only moving the modification time on when the content changed
+func (s *peopleServiceImpl) setDatasetDigest(digest datasetDigest) {
+	s.Lock()
+	defer s.Unlock()
+	s.updateDataset(digest)
+}
+
+//updateDataset must be called holding the lock
+func (s *peopleServiceImpl) updateDataset(digest datasetDigest) {
+	etag := digest.etag()
+	if etag != s.dataset.etag {
+		s.dataset.lastModified = time.Now()
+	}
+	s.dataset.etag = etag
+	s.dataset.generation++
+}
+
+//served must be called holding the lock
+func (s *peopleServiceImpl) served() cacheGeneration {
+	return cacheGenerations[s.current]
+}
+
 func (s *peopleServiceImpl) Shutdown() error {
 	log.Info("Shuting down...")
 	s.Lock()
@@ -98,9 +212,9 @@ func (s *peopleServiceImpl) getCount() (int, error) {
 
 	var count int
 	err := s.db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte(cacheBucket))
+		bucket := tx.Bucket([]byte(s.served().people))
 		if bucket == nil {
-			return fmt.Errorf("Bucket %v not found!", cacheBucket)
+			return fmt.Errorf("Bucket %v not found!", s.served().people)
 		}
 		count = bucket.Stats().KeyN
 		return nil
@@ -115,7 +229,7 @@ func (s *peopleServiceImpl) getPeople() (io.PipeReader, error) {
 		defer s.RUnlock()
 		defer pw.Close()
 		s.db.View(func(tx *bolt.Tx) error {
-			b := tx.Bucket([]byte(cacheBucket))
+			b := tx.Bucket([]byte(s.served().people))
 			c := b.Cursor()
 			for k, v := c.First(); k != nil; k, v = c.Next() {
 				if _, err := pw.Write(v); err != nil {
@@ -129,6 +243,34 @@ func (s *peopleServiceImpl) getPeople() (io.PipeReader, error) {
 	return *pv, nil
 }
 
+func (s *peopleServiceImpl) getPeopleAs(render personRenderer) (io.PipeReader, error) {
+	s.RLock()
+	pv, pw := io.Pipe()
+	go func() {
+		defer s.RUnlock()
+		defer pw.Close()
+		err := s.db.View(func(tx *bolt.Tx) error {
+			b := tx.Bucket([]byte(s.served().people))
+			c := b.Cursor()
+			for k, v := c.First(); k != nil; k, v = c.Next() {
+				var p person
+				if err := json.Unmarshal(v, &p); err != nil {
+					return err
+				}
+				if err := render(pw, p); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Errorf("ERROR rendering people: %v", err.Error())
+			pw.CloseWithError(err)
+		}
+	}()
+	return *pv, nil
+}
+
 func (s *peopleServiceImpl) getPeopleUUIDs() (io.PipeReader, error) {
 	s.RLock()
 	pv, pw := io.Pipe()
@@ -136,7 +278,7 @@ func (s *peopleServiceImpl) getPeopleUUIDs() (io.PipeReader, error) {
 		defer s.RUnlock()
 		defer pw.Close()
 		s.db.View(func(tx *bolt.Tx) error {
-			b := tx.Bucket([]byte(cacheBucket))
+			b := tx.Bucket([]byte(s.served().people))
 			c := b.Cursor()
 			encoder := json.NewEncoder(pw)
 			for k, _ := c.First(); k != nil; k, _ = c.Next() {
@@ -154,7 +296,11 @@ func (s *peopleServiceImpl) getPeopleUUIDs() (io.PipeReader, error) {
 	return *pv, nil
 }
 
-func (s *peopleServiceImpl) getPeopleLinks() (io.PipeReader, error) {
+func (s *peopleServiceImpl) getPeopleLinks(fwd forwardedHeaders) (io.PipeReader, error) {
+	base, err := linksBaseURL(s.baseURL, fwd)
+	if err != nil {
+		return io.PipeReader{}, err
+	}
 	s.RLock()
 	pv, pw := io.Pipe()
 	go func() {
@@ -162,7 +308,7 @@ func (s *peopleServiceImpl) getPeopleLinks() (io.PipeReader, error) {
 		defer pw.Close()
 		io.WriteString(pw, "[")
 		s.db.View(func(tx *bolt.Tx) error {
-			b := tx.Bucket([]byte(cacheBucket))
+			b := tx.Bucket([]byte(s.served().people))
 			c := b.Cursor()
 			encoder := json.NewEncoder(pw)
 			var k []byte
@@ -171,7 +317,7 @@ func (s *peopleServiceImpl) getPeopleLinks() (io.PipeReader, error) {
 				if k == nil {
 					break
 				}
-				pl := personLink{APIURL: s.baseURL + "/" + string(k[:])}
+				pl := personLink{APIURL: buildAPIURL(base, string(k[:]))}
 				if err := encoder.Encode(pl); err != nil {
 					return err
 				}
@@ -186,16 +332,42 @@ func (s *peopleServiceImpl) getPeopleLinks() (io.PipeReader, error) {
 	return *pv, nil
 }
 
+func (s *peopleServiceImpl) getStoredUUIDs() ([]string, error) {
+	s.RLock()
+	defer s.RUnlock()
+	var uuids []string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(s.served().people))
+		if bucket == nil {
+			return fmt.Errorf("Bucket %v not found!", s.served().people)
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			uuids = append(uuids, string(k))
+			return nil
+		})
+	})
+	return uuids, err
+}
+
 func (s *peopleServiceImpl) getPersonByUUID(uuid string) (person, bool, error) {
 	s.RLock()
 	defer s.RUnlock()
-	var cachedValue []byte
+	var cachedValue, cachedHash []byte
 	err := s.db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte(cacheBucket))
+		bucket := tx.Bucket([]byte(s.served().people))
 		if bucket == nil {
-			return fmt.Errorf("Bucket %v not found!", cacheBucket)
+			return fmt.Errorf("Bucket %v not found!", s.served().people)
+		}
+		key := []byte(uuid)
+		if aliases := tx.Bucket([]byte(s.served().aliases)); aliases != nil && bucket.Get(key) == nil {
+			if current := aliases.Get(key); current != nil {
+				key = current
+			}
+		}
+		cachedValue = bucket.Get(key)
+		if hashes := tx.Bucket([]byte(s.served().hashes)); hashes != nil {
+			cachedHash = hashes.Get(key)
 		}
-		cachedValue = bucket.Get([]byte(uuid))
 		return nil
 	})
 
@@ -213,12 +385,26 @@ func (s *peopleServiceImpl) getPersonByUUID(uuid string) (person, bool, error) {
 		log.Errorf("ERROR unmarshalling cached value for [%v]: %v.", uuid, err.Error())
 		return person{}, true, err
 	}
+	cachedPerson.Hash = string(cachedHash)
 	return cachedPerson, true, nil
 }
 
-func (s *peopleServiceImpl) openDB() error {
+//openDB empties the generation the load is written to, which is not the one served
+func (s *peopleServiceImpl) openDB() (cacheGeneration, error) {
 	s.Lock()
 	defer s.Unlock()
+	if err := s.openCacheFile(); err != nil {
+		return cacheGeneration{}, err
+	}
+	into := cacheGenerations[otherGeneration(s.current)]
+	if err := s.createCacheBucket(into); err != nil {
+		return cacheGeneration{}, err
+	}
+	return into, nil
+}
+
+//openCacheFile must be called holding the lock
+func (s *peopleServiceImpl) openCacheFile() error {
 	log.Infof("Opening database '%v'.", s.cacheFileName)
 	if s.db == nil {
 		var err error
@@ -227,98 +413,406 @@ func (s *peopleServiceImpl) openDB() error {
 			return err
 		}
 	}
-	return s.createCacheBucket()
+	return s.readCurrentGeneration()
+}
+
+//readCurrentGeneration must be called holding the lock
+func (s *peopleServiceImpl) readCurrentGeneration() error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		s.current = currentGeneration(tx)
+		return nil
+	})
+}
+
+//swapGeneration serves the generation just loaded, keeping the one it replaces as the previous generation
+func (s *peopleServiceImpl) swapGeneration(into cacheGeneration, digest datasetDigest) error {
+	s.Lock()
+	defer s.Unlock()
+	s.loading = false
+	s.discarded = false
+	s.rejectedLoad = nil
+	next := into.index()
+	if err := s.db.Update(func(tx *bolt.Tx) error {
+		return setCurrentGeneration(tx, next)
+	}); err != nil {
+		return err
+	}
+	s.current = next
+	s.updateDataset(digest)
+	s.dataLoaded = true
+	return s.db.View(func(tx *bolt.Tx) error {
+		if bucket := tx.Bucket([]byte(s.served().people)); bucket != nil {
+			datasetSize.Update(int64(bucket.Stats().KeyN))
+		}
+		return nil
+	})
+}
+
+//loadCachedDB serves the people already in the cache file, telling whether there were any
+func (s *peopleServiceImpl) loadCachedDB() (bool, error) {
+	s.Lock()
+	if err := s.openCacheFile(); err != nil {
+		s.Unlock()
+		return false, err
+	}
+	var count int
+	var digest datasetDigest
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(s.served().people))
+		hashes := tx.Bucket([]byte(s.served().hashes))
+		if bucket == nil || hashes == nil {
+			return nil
+		}
+		count = bucket.Stats().KeyN
+		return hashes.ForEach(func(k, v []byte) error {
+			return digest.addHex(v)
+		})
+	})
+	s.Unlock()
+	if err != nil || count == 0 {
+		return false, err
+	}
+
+	log.Infof("Found %v people in the cache file.", count)
+	datasetSize.Update(int64(count))
+	s.setDatasetDigest(digest)
+	s.setDataLoaded(true)
+	return true, nil
+}
+
+func (s *peopleServiceImpl) exportSnapshot(w io.Writer) error {
+	s.RLock()
+	defer s.RUnlock()
+	return writeSnapshot(s.db, w)
+}
+
+func (s *peopleServiceImpl) importSnapshot(r io.Reader) (int, error) {
+	if !s.startLoad() {
+		return 0, errAlreadyLoading
+	}
+	defer s.setLoading(false)
+	s.RLock()
+	count, digest, err := readSnapshot(s.db, r)
+	s.RUnlock()
+	if err != nil {
+		return 0, err
+	}
+	s.Lock()
+	err = s.readCurrentGeneration()
+	s.discarded = false
+	s.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	log.Infof("Imported a snapshot of %v people.", count)
+	datasetSize.Update(int64(count))
+	s.setDatasetDigest(digest)
+	s.setDataLoaded(true)
+	return count, nil
 }
 
-func (s *peopleServiceImpl) reloadDB() error {
-	s.setDataLoaded(false)
-	return s.loadDB()
+//reloadDB keeps serving the people already loaded until the new ones replace them
+func (s *peopleServiceImpl) reloadDB(ctx context.Context) error {
+	if !s.startLoad() {
+		return errAlreadyLoading
+	}
+	return s.loadDB(ctx)
 }
 
-func (s *peopleServiceImpl) loadDB() error {
+//startReload runs the reload in the background, false when people are already loading
+func (s *peopleServiceImpl) startReload(ctx context.Context) bool {
+	if !s.startLoad() {
+		return false
+	}
+	go func() {
+		if err := s.loadDB(ctx); err != nil {
+			log.Errorf("ERROR reloading people: %v", err.Error())
+		}
+	}()
+	return true
+}
+
+//loadDB carries on the transaction id of the context, e.g. of a reload request, or starts a new one
+func (s *peopleServiceImpl) loadDB(ctx context.Context) error {
+	transactionID, _ := transactionidutils.GetTransactionIDFromContext(ctx)
+	if transactionID == "" {
+		transactionID = transactionidutils.NewTransactionID()
+		ctx = transactionidutils.TransactionAwareContext(ctx, transactionID)
+	}
+	load := loadResult{loadID: uuid.New(), transactionID: transactionID, started: time.Now()}
+	logger := load.logger()
+	ctx = withLoadLogger(ctx, logger)
+	ctx, span := tracer.Start(ctx, "loadDB", trace.WithAttributes(transactionIDAttribute.String(transactionID)))
+	event(logger, "load_started").Info("Loading people from TME.")
+	err := s.fetchPeople(ctx)
+	loadDurationTimer.UpdateSince(load.started)
+	endSpan(span, err)
+	if err != nil {
+		event(logger, "load_failed").WithError(err).WithField("duration_ms", durationMillis(time.Since(load.started))).Error("Loading people failed.")
+	} else {
+		event(logger, "load_finished").WithFields(log.Fields{"duration_ms": durationMillis(time.Since(load.started)), "generation": s.getDatasetVersion().generation}).Info("Finished loading people.")
+		if _, err := s.getQualityReport(); err != nil {
+			event(logger, "quality_report_failed").WithError(err).Warn("Could not report on the quality of the people loaded.")
+		}
+	}
+	s.notifyLoadListeners(load, err)
+	return err
+}
+
+//fetchPeople returns once all the people fetched are processed
+func (s *peopleServiceImpl) fetchPeople(ctx context.Context) error {
 	var wg sync.WaitGroup
-	log.Info("Loading DB...")
-	c := make(chan []person)
-	go s.processPeople(c, &wg)
-	defer func(w *sync.WaitGroup) {
-		close(c)
-		w.Wait()
-	}(&wg)
-
-	if err := s.openDB(); err != nil {
+	into, err := s.openDB()
+	if err != nil {
 		s.setInitialised(false)
+		s.setLoading(false)
 		return err
 	}
 
+	c := make(chan transformedBatch)
+	processed := make(chan struct{})
+	var digest datasetDigest
+	var batchErr error
+	go func() {
+		digest, batchErr = s.processPeople(ctx, c, &wg, into)
+		close(processed)
+	}()
+	fetchErr := s.fetchTerms(ctx, c, &wg)
+	close(c)
+	wg.Wait()
+	<-processed
+	if fetchErr == nil {
+		//a batch missing from the load would have its people reported as removed
+		fetchErr = batchErr
+	}
+	return s.finishLoad(ctx, into, digest, fetchErr)
+}
+
+func (s *peopleServiceImpl) fetchTerms(ctx context.Context, c chan<- transformedBatch, wg *sync.WaitGroup) error {
+	logger := loadLogger(ctx)
 	responseCount := 0
-	for {
+	for page := 0; ; page++ {
+		_, span := tracer.Start(ctx, "GetTmeTermsFromIndex", trace.WithAttributes(attribute.Int("tme.start_record", responseCount)))
+		started := time.Now()
 		terms, err := s.repository.GetTmeTermsFromIndex(responseCount)
+		tmePageFetchTimer.UpdateSince(started)
+		span.SetAttributes(attribute.Int("tme.terms", len(terms)))
+		endSpan(span, err)
+		pageLogger := logger.WithFields(log.Fields{"page": page, "start_record": responseCount, "duration_ms": durationMillis(time.Since(started))})
 		if err != nil {
+			event(pageLogger, "tme_page_failed").WithError(err).Error("Fetching a page of people from TME failed.")
 			return err
 		}
 		if len(terms) < 1 {
-			log.Info("Finished fetching people from TME. Waiting subroutines to terminate.")
+			event(pageLogger, "tme_fetch_finished").WithField("pages", page).Info("Finished fetching people from TME. Waiting subroutines to terminate.")
 			break
 		}
+		event(pageLogger, "tme_page_fetched").WithField("count", len(terms)).Debug("Fetched a page of people from TME.")
 
+		tmePagesFetched.Inc(1)
 		wg.Add(1)
-		s.processTerms(terms, c)
+		s.processTerms(ctx, terms, c)
 		responseCount += s.maxTmeRecords
 	}
 	return nil
 }
 
-func (s *peopleServiceImpl) processTerms(terms []interface{}, c chan<- []person) {
-	log.Info("Processing terms...")
-	var cacheToBeWritten []person
+//finishLoad serves the generation loaded, unless fetching it failed or the shrink guard rejects it
+func (s *peopleServiceImpl) finishLoad(ctx context.Context, into cacheGeneration, digest datasetDigest, fetchErr error) error {
+	if !s.isInitialised() {
+		s.setLoading(false)
+		return fetchErr
+	}
+	if fetchErr != nil {
+		event(loadLogger(ctx), "load_discarded").WithError(fetchErr).Error("Load failed, the people served are kept.")
+		s.discardLoad()
+		return fetchErr
+	}
+	if err := s.guardShrink(into); err != nil {
+		event(loadLogger(ctx), "load_rejected").WithError(err).Error("Load rejected, the people served are kept.")
+		s.rejectLoad(err)
+		return err
+	}
+	if err := s.swapGeneration(into, digest); err != nil {
+		event(loadLogger(ctx), "swap_failed").WithError(err).Error("Serving the people loaded failed.")
+		return err
+	}
+	s.Lock()
+	s.lastLoad = time.Now()
+	s.Unlock()
+	lastLoadTimestamp.Update(time.Now().Unix())
+	return nil
+}
+
+//discardLoad keeps serving the people of the previous load after a load failed, the generation loaded into no longer holds the load before
+func (s *peopleServiceImpl) discardLoad() {
+	s.Lock()
+	defer s.Unlock()
+	s.loading = false
+	s.discarded = true
+}
+
+//notifyLoadListeners works out the result of the load, then queues it for each listener without waiting for them
+func (s *peopleServiceImpl) notifyLoadListeners(load loadResult, err error) {
+	if len(s.loadListeners) == 0 {
+		return
+	}
+	load.duration = time.Since(load.started)
+	load.err = err
+	load.generation = s.getDatasetVersion().generation
+	if err == nil {
+		load.count, load.err = s.getCount()
+	}
+	if load.err == nil {
+		load.changes, load.err = s.detectChanges()
+	}
+	for _, queue := range s.listeners {
+		queue.push(load)
+	}
+}
+
+//getDatasetDiff compares the people served with the previous generation, unless it is being loaded into or holds a failed or rejected load
+func (s *peopleServiceImpl) getDatasetDiff() (datasetDiff, bool, error) {
+	s.RLock()
+	defer s.RUnlock()
+	if s.loading || s.discarded {
+		return datasetDiff{}, false, nil
+	}
+	var diff datasetDiff
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		diff, err = newDatasetDiff(tx, cacheGenerations[otherGeneration(s.current)], s.served())
+		return err
+	})
+	return diff, err == nil, err
+}
+
+//detectChanges compares the generation served with the previous one
+func (s *peopleServiceImpl) detectChanges() ([]personChange, error) {
+	s.RLock()
+	defer s.RUnlock()
+	var changes []personChange
+	err := s.db.View(func(tx *bolt.Tx) error {
+		changes = diffGenerations(tx, cacheGenerations[otherGeneration(s.current)], s.served())
+		return nil
+	})
+	return changes, err
+}
+
+//processTerms quarantines the invalid terms, so they are reported instead of failing the batch they are in
+func (s *peopleServiceImpl) processTerms(ctx context.Context, terms []interface{}, c chan<- transformedBatch) {
+	logger := loadLogger(ctx)
+	_, span := tracer.Start(ctx, "processTerms", trace.WithAttributes(attribute.Int("tme.terms", len(terms))))
+	var batch transformedBatch
 	for _, iTerm := range terms {
-		t := iTerm.(term)
-		cacheToBeWritten = append(cacheToBeWritten, transformPerson(t, s.taxonomyName))
+		t, err := validateTerm(iTerm)
+		if err != nil {
+			quarantined := quarantineTerm(iTerm, err)
+			event(logger, "term_quarantined").WithFields(log.Fields{"id": quarantined.ID, "reason": quarantined.Reason}).Warn("Quarantined an invalid term.")
+			batch.quarantined = append(batch.quarantined, quarantined)
+			continue
+		}
+		batch.people = append(batch.people, transformPerson(t, s.taxonomyName, s.uuidScheme, s.migratedFrom...))
 	}
-	c <- cacheToBeWritten
+	termsTransformed.Inc(int64(len(batch.people)))
+	transformationErrors.Inc(int64(len(batch.quarantined)))
+	span.End()
+	event(logger, "terms_transformed").WithFields(log.Fields{"count": len(batch.people), "quarantined": len(batch.quarantined)}).Debug("Transformed a page of terms.")
+	c <- batch
 }
 
-func (s *peopleServiceImpl) processPeople(c <-chan []person, wg *sync.WaitGroup) {
-	for people := range c {
-		log.Infof("Processing batch of %v people.", len(people))
-		if err := s.db.Batch(func(tx *bolt.Tx) error {
-			bucket := tx.Bucket([]byte(cacheBucket))
+//processPeople stores the batches into the generation, it returns the error of the first batch that could not be stored
+func (s *peopleServiceImpl) processPeople(ctx context.Context, c <-chan transformedBatch, wg *sync.WaitGroup, into cacheGeneration) (datasetDigest, error) {
+	logger := loadLogger(ctx)
+	var digest datasetDigest
+	var batchErr error
+	stored := 0
+	for batch := range c {
+		people := batch.people
+		var batchDigest datasetDigest
+		var unmarshallable int
+		_, span := tracer.Start(ctx, "db.Batch", trace.WithAttributes(attribute.Int("people", len(people))))
+		started := time.Now()
+		err := s.db.Batch(func(tx *bolt.Tx) error {
+			batchDigest = datasetDigest{}
+			unmarshallable = 0
+			bucket := tx.Bucket([]byte(into.people))
 			if bucket == nil {
-				return fmt.Errorf("Cache bucket [%v] not found!", cacheBucket)
+				return fmt.Errorf("Cache bucket [%v] not found!", into.people)
+			}
+			hashes := tx.Bucket([]byte(into.hashes))
+			if hashes == nil {
+				return fmt.Errorf("Cache bucket [%v] not found!", into.hashes)
+			}
+			quarantine := tx.Bucket([]byte(into.quarantine))
+			if quarantine == nil {
+				return fmt.Errorf("Cache bucket [%v] not found!", into.quarantine)
+			}
+			aliases := tx.Bucket([]byte(into.aliases))
+			if aliases == nil {
+				return fmt.Errorf("Cache bucket [%v] not found!", into.aliases)
+			}
+			for _, quarantined := range batch.quarantined {
+				if err := putQuarantined(quarantine, quarantined); err != nil {
+					return err
+				}
 			}
 			for _, anPerson := range people {
 				marshalledPerson, err := json.Marshal(anPerson)
 				if err != nil {
-					return err
+					unmarshallable++
+					if err := putQuarantined(quarantine, quarantinedTerm{ID: anPerson.UUID, Reason: err.Error(), Term: fmt.Sprintf("%+v", anPerson)}); err != nil {
+						return err
+					}
+					continue
 				}
 				err = bucket.Put([]byte(anPerson.UUID), marshalledPerson)
 				if err != nil {
 					return err
 				}
+				sum := contentHash(marshalledPerson)
+				err = hashes.Put([]byte(anPerson.UUID), []byte(hex.EncodeToString(sum[:])))
+				if err != nil {
+					return err
+				}
+				if err := putAliases(aliases, anPerson); err != nil {
+					return err
+				}
+				batchDigest.add(sum)
 			}
 			return nil
-		}); err != nil {
-			log.Errorf("ERROR storing to cache: %+v.", err)
+		})
+		cacheBatchWriteTimer.UpdateSince(started)
+		endSpan(span, err)
+		batchLogger := logger.WithFields(log.Fields{"count": len(people), "duration_ms": durationMillis(time.Since(started))})
+		if err != nil {
+			event(batchLogger, "batch_failed").WithError(err).Error("Storing a batch of people to the cache file failed.")
+			if batchErr == nil {
+				batchErr = err
+			}
+		} else {
+			digest.merge(batchDigest)
+			transformationErrors.Inc(int64(unmarshallable))
+			peopleStored.Inc(int64(len(people) - unmarshallable))
+			stored += len(people) - unmarshallable
+			event(batchLogger, "batch_stored").Debug("Stored a batch of people to the cache file.")
 		}
 		wg.Done()
 	}
 
-	log.Info("Finished processing all people.")
-	if s.isInitialised() {
-		s.setDataLoaded(true)
-	}
+	event(logger, "people_stored").WithField("count", stored).Info("Finished processing all people.")
+	return digest, batchErr
 }
 
-func (s *peopleServiceImpl) createCacheBucket() error {
+//createCacheBucket must be called holding the lock, it also makes sure there is a generation to serve while loading the first one
+func (s *peopleServiceImpl) createCacheBucket(g cacheGeneration) error {
 	return s.db.Update(func(tx *bolt.Tx) error {
-		if tx.Bucket([]byte(cacheBucket)) != nil {
-			log.Infof("Deleting bucket '%v'.", cacheBucket)
-			if err := tx.DeleteBucket([]byte(cacheBucket)); err != nil {
-				log.Warnf("Cache bucket [%v] could not be deleted.", cacheBucket)
+		for _, name := range []string{s.served().people, s.served().hashes, s.served().quarantine, s.served().aliases} {
+			if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+				return err
 			}
 		}
-		log.Infof("Creating bucket '%s'.", cacheBucket)
-		_, err := tx.CreateBucket([]byte(cacheBucket))
+		_, _, err := g.recreate(tx)
 		return err
 	})
 }
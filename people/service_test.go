@@ -2,13 +2,18 @@ package people
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/Financial-Times/tme-reader/tmereader"
 	log "github.com/Sirupsen/logrus"
+	"github.com/boltdb/bolt"
 	"github.com/stretchr/testify/assert"
 	"io"
 	"io/ioutil"
 	"os"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -68,6 +73,32 @@ func TestGetPeople(t *testing.T) {
 	assert.Equal(t, "be2e7e2b-0fa2-3969-a69b-74c46e754032", res[1].UUID)
 }
 
+func TestGetPeopleAs(t *testing.T) {
+	tmpfile := getTempFile(t)
+	defer os.Remove(tmpfile.Name())
+	repo := dummyRepo{terms: []term{{CanonicalName: "Bob", RawID: "bob"}, {CanonicalName: "Fred", RawID: "fred"}}}
+	service := createTestPeopleService(&repo, tmpfile.Name())
+	defer service.Shutdown()
+	waitTillInit(t, service)
+	waitTillDataLoaded(t, service)
+	pv, err := service.getPeopleAs(peopleV2.render)
+	assert.NoError(t, err)
+
+	var res []personV2
+	scan := bufio.NewScanner(&pv)
+	for scan.Scan() {
+		var p personV2
+		assert.NoError(t, json.Unmarshal(scan.Bytes(), &p))
+		res = append(res, p)
+	}
+	assert.NoError(t, scan.Err())
+	assert.Len(t, res, 2)
+	assert.Equal(t, "28d66fcc-bb56-363d-80c1-f2d957ef58cf", res[0].UUID)
+	assert.Equal(t, "Fred", res[0].PrefLabel)
+	assert.Equal(t, []provenance{{Authority: tmeAuthority, Taxonomy: "taxonomy_string", TermID: "fred"}}, res[0].Provenance)
+	assert.Equal(t, "be2e7e2b-0fa2-3969-a69b-74c46e754032", res[1].UUID)
+}
+
 func TestGetPeopleByUUID(t *testing.T) {
 	tmpfile := getTempFile(t)
 	defer os.Remove(tmpfile.Name())
@@ -109,7 +140,7 @@ func TestGetPeopleLink(t *testing.T) {
 	defer service.Shutdown()
 	waitTillInit(t, service)
 	waitTillDataLoaded(t, service)
-	pv, err := service.getPeopleLinks()
+	pv, err := service.getPeopleLinks(forwardedHeaders{})
 
 	var wg sync.WaitGroup
 	var res []personLink
@@ -153,12 +184,148 @@ func TestReload(t *testing.T) {
 	assertCount(t, service, 2)
 	repo.terms = append(repo.terms, term{CanonicalName: "Third", RawID: "third"})
 	repo.count = 0
-	assert.NoError(t, service.reloadDB())
+	assert.NoError(t, service.reloadDB(context.Background()))
 	waitTillInit(t, service)
 	waitTillDataLoaded(t, service)
 	assertCount(t, service, 3)
 }
 
+func TestServesPreviousPeopleWhileReloading(t *testing.T) {
+	tmpfile := getTempFile(t)
+	defer os.Remove(tmpfile.Name())
+	repo := blockingRepo{}
+	service := createTestPeopleService(&repo, tmpfile.Name())
+	defer service.Shutdown()
+	waitTillDataLoaded(t, service)
+	assert.False(t, service.isLoading())
+
+	repo.Add(1)
+	repo.done = false
+	reloaded := make(chan error)
+	go func() {
+		reloaded <- service.reloadDB(context.Background())
+	}()
+	for i := 0; i < 100 && !service.isLoading(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, service.isLoading())
+	assert.True(t, service.isDataLoaded(), "The people of the previous load are still served")
+	assertCount(t, service, 1)
+
+	repo.Done()
+	assert.NoError(t, <-reloaded)
+	assert.False(t, service.isLoading())
+	assertCount(t, service, 1)
+}
+
+func TestFailedReloadKeepsPeopleServed(t *testing.T) {
+	tmpfile := getTempFile(t)
+	defer os.Remove(tmpfile.Name())
+	repo := dummyRepo{terms: []term{{CanonicalName: "Bob", RawID: "bob"}, {CanonicalName: "Fred", RawID: "fred"}}}
+	service := createTestPeopleService(&repo, tmpfile.Name())
+	defer service.Shutdown()
+	waitTillDataLoaded(t, service)
+	served := service.getDatasetVersion()
+
+	repo.count = 0
+	repo.err = errors.New("TME is down")
+	assert.Equal(t, repo.err, service.reloadDB(context.Background()))
+	assert.False(t, service.isLoading())
+	assert.True(t, service.isDataLoaded())
+	assertCount(t, service, 2)
+	_, found, err := service.getPersonByUUID(fredUUID)
+	assert.NoError(t, err)
+	assert.True(t, found, "The people served before the failed load are kept")
+	assert.Equal(t, served, service.getDatasetVersion())
+	_, available, err := service.getDatasetDiff()
+	assert.NoError(t, err)
+	assert.False(t, available, "The failed load is not diffed against")
+}
+
+func TestFailedBatchKeepsPeopleServed(t *testing.T) {
+	tmpfile := getTempFile(t)
+	defer os.Remove(tmpfile.Name())
+	repo := blockingRepo{}
+	service := createTestPeopleService(&repo, tmpfile.Name())
+	defer service.Shutdown()
+	waitTillDataLoaded(t, service)
+
+	repo.Add(1)
+	repo.done = false
+	reloaded := make(chan error)
+	go func() {
+		reloaded <- service.reloadDB(context.Background())
+	}()
+	for i := 0; i < 100 && !service.isLoading(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	impl := service.(*peopleServiceImpl)
+	impl.RLock()
+	into := cacheGenerations[otherGeneration(impl.current)]
+	impl.RUnlock()
+	assert.NoError(t, impl.db.Update(func(tx *bolt.Tx) error {
+		return tx.DeleteBucket([]byte(into.hashes))
+	}))
+
+	repo.Done()
+	assert.EqualError(t, <-reloaded, fmt.Sprintf("Cache bucket [%v] not found!", into.hashes))
+	assert.False(t, service.isLoading())
+	assertCount(t, service, 1)
+	_, available, err := service.getDatasetDiff()
+	assert.NoError(t, err)
+	assert.False(t, available, "The load missing a batch is not served")
+}
+
+func TestFailedFirstLoadCanBeReloaded(t *testing.T) {
+	tmpfile := getTempFile(t)
+	defer os.Remove(tmpfile.Name())
+	repo := dummyRepo{terms: []term{{CanonicalName: "Bob", RawID: "bob"}}, err: errors.New("TME is down")}
+	listener := &recordingListener{}
+	service := NewPeopleService(&repo, "/base/url", "taxonomy_string", 1, tmpfile.Name(), withLoadListener(listener))
+	defer service.Shutdown()
+	assert.Equal(t, repo.err, listener.waitForLoads(t, 1).err)
+	assert.False(t, service.isLoading())
+	assert.False(t, service.isDataLoaded())
+	assert.True(t, service.isInitialised())
+
+	repo.err = nil
+	repo.count = 0
+	assert.True(t, service.startReload(context.Background()))
+	assert.NoError(t, listener.waitForLoads(t, 2).err)
+	assert.True(t, service.isDataLoaded())
+	assertCount(t, service, 1)
+}
+
+func TestOnlyOneLoadAtATime(t *testing.T) {
+	tmpfile := getTempFile(t)
+	defer os.Remove(tmpfile.Name())
+	repo := blockingRepo{}
+	service := createTestPeopleService(&repo, tmpfile.Name())
+	defer service.Shutdown()
+	waitTillDataLoaded(t, service)
+	loaded := service.getDatasetVersion()
+
+	repo.Add(1)
+	repo.done = false
+	assert.True(t, service.startReload(context.Background()))
+	assert.False(t, service.startReload(context.Background()), "A reload is already running")
+	assert.Equal(t, errAlreadyLoading, service.reloadDB(context.Background()))
+	_, err := service.importSnapshot(strings.NewReader(""))
+	assert.Equal(t, errAlreadyLoading, err)
+
+	repo.Done()
+	for i := 0; i < 100 && service.isLoading(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.False(t, service.isLoading())
+	assert.Equal(t, loaded.generation+1, service.getDatasetVersion().generation, "Only the first reload is served")
+	assertCount(t, service, 1)
+	impl := service.(*peopleServiceImpl)
+	impl.RLock()
+	defer impl.RUnlock()
+	assert.Equal(t, 0, impl.current, "The generation reloaded into is served, not the one it replaced")
+}
+
 func TestGetPersonByUUID(t *testing.T) {
 	tmpfile := getTempFile(t)
 	defer os.Remove(tmpfile.Name())
@@ -184,6 +351,49 @@ func TestGetPersonByUUID(t *testing.T) {
 	}
 }
 
+func TestPersonAndDatasetHashes(t *testing.T) {
+	tmpfile := getTempFile(t)
+	defer os.Remove(tmpfile.Name())
+	repo := dummyRepo{terms: []term{{CanonicalName: "Bob", RawID: "bob"}, {CanonicalName: "Fred", RawID: "fred"}}}
+	service := createTestPeopleService(&repo, tmpfile.Name())
+	defer service.Shutdown()
+	waitTillInit(t, service)
+	waitTillDataLoaded(t, service)
+
+	p, found, err := service.getPersonByUUID("be2e7e2b-0fa2-3969-a69b-74c46e754032")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Len(t, p.Hash, 64)
+
+	first := service.getDatasetVersion()
+	assert.Equal(t, 1, first.generation)
+	assert.NotEmpty(t, first.etag)
+	assert.False(t, first.lastModified.IsZero())
+
+	repo.count = 0
+	assert.NoError(t, service.reloadDB(context.Background()))
+	waitTillDataLoaded(t, service)
+	unchanged := service.getDatasetVersion()
+	assert.Equal(t, 2, unchanged.generation)
+	assert.Equal(t, first.etag, unchanged.etag)
+	assert.Equal(t, first.lastModified, unchanged.lastModified)
+
+	repo.terms[1].CanonicalName = "Frederick"
+	repo.count = 0
+	assert.NoError(t, service.reloadDB(context.Background()))
+	waitTillDataLoaded(t, service)
+	changed := service.getDatasetVersion()
+	assert.Equal(t, 3, changed.generation)
+	assert.NotEqual(t, first.etag, changed.etag)
+
+	other, _, err := service.getPersonByUUID("28d66fcc-bb56-363d-80c1-f2d957ef58cf")
+	assert.NoError(t, err)
+	assert.Equal(t, "Frederick", other.PrefLabel)
+	p2, _, err := service.getPersonByUUID("be2e7e2b-0fa2-3969-a69b-74c46e754032")
+	assert.NoError(t, err)
+	assert.Equal(t, p.Hash, p2.Hash)
+}
+
 func TestFailingOpeningDB(t *testing.T) {
 	dir, err := ioutil.TempDir("", "service_test")
 	assert.NoError(t, err)
@@ -0,0 +1,181 @@
+package people
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+const snapshotVersion = 1
+
+//snapshotTrailer closes a snapshot, after one stored person per line, with the checksum of all the person lines
+type snapshotTrailer struct {
+	Version int    `json:"version"`
+	Count   int    `json:"count"`
+	SHA256  string `json:"sha256"`
+}
+
+type snapshotLine struct {
+	UUID                   string                 `json:"uuid"`
+	AlternativeIdentifiers alternativeIdentifiers `json:"alternativeIdentifiers"`
+	Snapshot               *snapshotTrailer       `json:"snapshot"`
+}
+
+//ExportSnapshot writes the people in a cache file as a gzipped, checksummed NDJSON snapshot
+func ExportSnapshot(cacheFileName string, w io.Writer) error {
+	db, err := bolt.Open(cacheFileName, 0600, &bolt.Options{Timeout: 1 * time.Second, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return writeSnapshot(db, w)
+}
+
+//ImportSnapshot loads a snapshot written by ExportSnapshot into a cache file with no people in it
+func ImportSnapshot(cacheFileName string, r io.Reader) (int, error) {
+	db, err := bolt.Open(cacheFileName, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	var count int
+	err = db.View(func(tx *bolt.Tx) error {
+		if bucket := tx.Bucket([]byte(cacheGenerations[currentGeneration(tx)].people)); bucket != nil {
+			count = bucket.Stats().KeyN
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		return 0, fmt.Errorf("Cache file %v already holds %v people", cacheFileName, count)
+	}
+	count, _, err = readSnapshot(db, r)
+	return count, err
+}
+
+func writeSnapshot(db *bolt.DB, w io.Writer) error {
+	if db == nil {
+		return errors.New("DB not open")
+	}
+	zw := gzip.NewWriter(w)
+	err := db.View(func(tx *bolt.Tx) error {
+		served := cacheGenerations[currentGeneration(tx)]
+		bucket := tx.Bucket([]byte(served.people))
+		if bucket == nil {
+			return fmt.Errorf("Bucket %v not found!", served.people)
+		}
+		checksum := sha256.New()
+		out := io.MultiWriter(zw, checksum)
+		count := 0
+		c := bucket.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if _, err := out.Write(v); err != nil {
+				return err
+			}
+			if _, err := io.WriteString(out, "\n"); err != nil {
+				return err
+			}
+			count++
+		}
+		return json.NewEncoder(zw).Encode(snapshotLine{Snapshot: &snapshotTrailer{Version: snapshotVersion, Count: count, SHA256: hex.EncodeToString(checksum.Sum(nil))}})
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+//readSnapshot serves the people in the snapshot as a new generation, leaving the cache untouched unless the whole snapshot is valid
+func readSnapshot(db *bolt.DB, r io.Reader) (int, datasetDigest, error) {
+	var digest datasetDigest
+	if db == nil {
+		return 0, digest, errors.New("DB not open")
+	}
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, digest, err
+	}
+	defer zr.Close()
+
+	count := 0
+	err = db.Update(func(tx *bolt.Tx) error {
+		next := otherGeneration(currentGeneration(tx))
+		bucket, hashes, err := cacheGenerations[next].recreate(tx)
+		if err != nil {
+			return err
+		}
+		aliases := tx.Bucket([]byte(cacheGenerations[next].aliases))
+
+		checksum := sha256.New()
+		lines := bufio.NewReader(zr)
+		for {
+			line, err := lines.ReadBytes('\n')
+			if err == io.EOF && len(line) == 0 {
+				return errors.New("Snapshot is truncated, its trailer is missing")
+			}
+			if err != nil && err != io.EOF {
+				return err
+			}
+			var parsed snapshotLine
+			if err := json.Unmarshal(line, &parsed); err != nil {
+				return fmt.Errorf("Snapshot line %v is not valid json: %v", count+1, err)
+			}
+			if parsed.Snapshot != nil {
+				if err := verifySnapshot(parsed.Snapshot, count, checksum); err != nil {
+					return err
+				}
+				return setCurrentGeneration(tx, next)
+			}
+			if err == io.EOF {
+				return errors.New("Snapshot is truncated, its trailer is missing")
+			}
+			if parsed.UUID == "" {
+				return fmt.Errorf("Snapshot line %v has no uuid", count+1)
+			}
+
+			checksum.Write(line)
+			value := line[:len(line)-1]
+			sum := contentHash(value)
+			if err := bucket.Put([]byte(parsed.UUID), value); err != nil {
+				return err
+			}
+			if err := hashes.Put([]byte(parsed.UUID), []byte(hex.EncodeToString(sum[:]))); err != nil {
+				return err
+			}
+			if err := putAliases(aliases, person{UUID: parsed.UUID, AlternativeIdentifiers: parsed.AlternativeIdentifiers}); err != nil {
+				return err
+			}
+			digest.add(sum)
+			count++
+		}
+	})
+	if err != nil {
+		return 0, datasetDigest{}, err
+	}
+	return count, digest, nil
+}
+
+func verifySnapshot(trailer *snapshotTrailer, count int, checksum hash.Hash) error {
+	if trailer.Version != snapshotVersion {
+		return fmt.Errorf("Unsupported snapshot version %v", trailer.Version)
+	}
+	if trailer.Count != count {
+		return fmt.Errorf("Snapshot holds %v people but its trailer expects %v", count, trailer.Count)
+	}
+	if sum := hex.EncodeToString(checksum.Sum(nil)); sum != trailer.SHA256 {
+		return fmt.Errorf("Snapshot checksum %v does not match its trailer %v", sum, trailer.SHA256)
+	}
+	return nil
+}
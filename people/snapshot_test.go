@@ -0,0 +1,118 @@
+package people
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	tmpfile := getTempFile(t)
+	defer os.Remove(tmpfile.Name())
+	repo := dummyRepo{terms: []term{{CanonicalName: "Bob", RawID: "bob"}, {CanonicalName: "Fred", RawID: "fred"}}}
+	service := createTestPeopleService(&repo, tmpfile.Name())
+	waitTillInit(t, service)
+	waitTillDataLoaded(t, service)
+	loaded := service.getDatasetVersion()
+	assert.NoError(t, service.Shutdown())
+
+	var snapshot bytes.Buffer
+	assert.NoError(t, ExportSnapshot(tmpfile.Name(), &snapshot))
+
+	imported := getTempFile(t)
+	defer os.Remove(imported.Name())
+	count, err := ImportSnapshot(imported.Name(), bytes.NewReader(snapshot.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	emptyRepo := dummyRepo{}
+	fromCache := NewPeopleService(&emptyRepo, "/base/url", "taxonomy_string", 1, imported.Name(), WithCachedDataOnStart())
+	defer fromCache.Shutdown()
+	waitTillInit(t, fromCache)
+	waitTillDataLoaded(t, fromCache)
+	assertCount(t, fromCache, 2)
+	assert.Equal(t, 0, emptyRepo.count, "TME should not be queried when the cache holds people")
+	assert.Equal(t, loaded.etag, fromCache.getDatasetVersion().etag)
+
+	p, found, err := fromCache.getPersonByUUID("28d66fcc-bb56-363d-80c1-f2d957ef58cf")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "Fred", p.PrefLabel)
+}
+
+func TestImportSnapshotIntoNonEmptyCache(t *testing.T) {
+	tmpfile := getTempFile(t)
+	defer os.Remove(tmpfile.Name())
+	repo := dummyRepo{terms: []term{{CanonicalName: "Bob", RawID: "bob"}}}
+	service := createTestPeopleService(&repo, tmpfile.Name())
+	waitTillInit(t, service)
+	waitTillDataLoaded(t, service)
+	assert.NoError(t, service.Shutdown())
+
+	var snapshot bytes.Buffer
+	assert.NoError(t, ExportSnapshot(tmpfile.Name(), &snapshot))
+	_, err := ImportSnapshot(tmpfile.Name(), &snapshot)
+	assert.Error(t, err)
+}
+
+func TestImportInvalidSnapshot(t *testing.T) {
+	tmpfile := getTempFile(t)
+	defer os.Remove(tmpfile.Name())
+	repo := dummyRepo{terms: []term{{CanonicalName: "Bob", RawID: "bob"}, {CanonicalName: "Fred", RawID: "fred"}}}
+	service := createTestPeopleService(&repo, tmpfile.Name())
+	defer service.Shutdown()
+	waitTillInit(t, service)
+	waitTillDataLoaded(t, service)
+
+	var snapshot bytes.Buffer
+	assert.NoError(t, service.exportSnapshot(&snapshot))
+	lines := strings.SplitAfter(gunzipString(t, snapshot.Bytes()), "\n")
+	assert.Len(t, lines, 4)
+
+	tests := []struct {
+		name     string
+		snapshot []byte
+		err      string
+	}{
+		{"Not gzipped", []byte(lines[0]), "gzip"},
+		{"Trailer missing", gzipString(t, lines[0]+lines[1]), "truncated"},
+		{"Person missing", gzipString(t, lines[0]+lines[2]), "Snapshot holds 1 people but its trailer expects 2"},
+		{"Person changed", gzipString(t, strings.Replace(lines[0]+lines[1], "Fred", "Frederick", 1)+lines[2]), "checksum"},
+		{"Not json", gzipString(t, "person\n"+lines[2]), "Snapshot line 1 is not valid json"},
+		{"Unsupported version", gzipString(t, lines[0]+lines[1]+strings.Replace(lines[2], `"version":1`, `"version":2`, 1)), "Unsupported snapshot version 2"},
+	}
+	for _, test := range tests {
+		_, err := service.importSnapshot(bytes.NewReader(test.snapshot))
+		if assert.Error(t, err, test.name) {
+			assert.Contains(t, err.Error(), test.err, test.name)
+		}
+		assertCount(t, service, 2)
+	}
+
+	count, err := service.importSnapshot(bytes.NewReader(snapshot.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assertCount(t, service, 2)
+}
+
+func gzipString(t *testing.T, s string) []byte {
+	var b bytes.Buffer
+	zw := gzip.NewWriter(&b)
+	_, err := zw.Write([]byte(s))
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+	return b.Bytes()
+}
+
+func gunzipString(t *testing.T, b []byte) string {
+	zr, err := gzip.NewReader(bytes.NewReader(b))
+	assert.NoError(t, err)
+	s, err := ioutil.ReadAll(zr)
+	assert.NoError(t, err)
+	return string(s)
+}
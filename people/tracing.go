@@ -0,0 +1,75 @@
+package people
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Financial-Times/transactionid-utils-go"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//tracer starts the spans of the requests and the loads, exported by the tracer provider main sets up
+var tracer = otel.Tracer("github.com/Financial-Times/v1-people-transformer/people")
+
+const transactionIDAttribute = attribute.Key("ft.transaction_id")
+
+//TracingHandler starts a span per request named after its route, carrying the FT transaction id in the span and the request context
+func TracingHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		name := req.Method
+		if route := mux.CurrentRoute(req); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil {
+				name += " " + template
+			}
+		}
+		transactionID := writer.Header().Get(transactionidutils.TransactionIDHeader)
+		if transactionID == "" {
+			transactionID = transactionidutils.GetTransactionIDFromRequest(req)
+		}
+		ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+		ctx = transactionidutils.TransactionAwareContext(ctx, transactionID)
+		ctx, span := tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(transactionIDAttribute.String(transactionID), attribute.String("http.method", req.Method), attribute.String("http.target", req.URL.Path)))
+		defer span.End()
+
+		recorder := &statusRecorder{ResponseWriter: writer, status: http.StatusOK}
+		next.ServeHTTP(recorder, req.WithContext(ctx))
+		span.SetAttributes(attribute.Int("http.status_code", recorder.status))
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.status))
+		}
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+//detach keeps the span and the transaction id of a request for the work outliving it, e.g. a reload
+func detach(ctx context.Context) context.Context {
+	detached := trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx))
+	if transactionID, _ := transactionidutils.GetTransactionIDFromContext(ctx); transactionID != "" {
+		detached = transactionidutils.TransactionAwareContext(detached, transactionID)
+	}
+	return detached
+}
+
+//endSpan records the error the span ended with, if any
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,95 @@
+package people
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/Financial-Times/transactionid-utils-go"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var (
+	spanExporter     = tracetest.NewInMemoryExporter()
+	spanExporterOnce sync.Once
+)
+
+//recordSpans sets the tracer provider once, as the tracer keeps delegating to the first one set
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	spanExporterOnce.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spanExporter)))
+	})
+	spanExporter.Reset()
+	return spanExporter
+}
+
+func spanAttribute(span tracetest.SpanStub, key string) string {
+	for _, attribute := range span.Attributes {
+		if string(attribute.Key) == key {
+			return attribute.Value.Emit()
+		}
+	}
+	return ""
+}
+
+func TestRequestSpans(t *testing.T) {
+	exporter := recordSpans(t)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	s := &dummyService{wg: &wg, initialised: true, dataLoaded: true, count: 2, people: []person{{}}}
+
+	req := newRequest("POST", "/transformers/people/__reload")
+	req.Header.Set(transactionidutils.TransactionIDHeader, "tid_reload")
+	rec := httptest.NewRecorder()
+	router(s).ServeHTTP(rec, req)
+	wg.Wait()
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router(s).ServeHTTP(rec, newRequest("GET", "/transformers/people/"+testUUID))
+
+	spans := exporter.GetSpans()
+	if assert.Len(t, spans, 2) {
+		assert.Equal(t, "POST /transformers/people/__reload", spans[0].Name)
+		assert.Equal(t, "tid_reload", spanAttribute(spans[0], "ft.transaction_id"))
+		assert.Equal(t, "GET /transformers/people/{uuid:([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})}", spans[1].Name)
+		assert.Equal(t, "404", spanAttribute(spans[1], "http.status_code"))
+	}
+}
+
+func TestLoadSpans(t *testing.T) {
+	exporter := recordSpans(t)
+	tmpfile := getTempFile(t)
+	defer os.Remove(tmpfile.Name())
+	repo := dummyRepo{terms: []term{{CanonicalName: "Bob", RawID: "bob"}, {CanonicalName: "Fred", RawID: "fred"}}}
+	listener := &recordingListener{}
+	service := NewPeopleService(&repo, "/base/url", "taxonomy_string", 1, tmpfile.Name(), withLoadListener(listener))
+	defer service.Shutdown()
+	listener.waitForLoads(t, 1)
+	exporter.Reset()
+
+	repo.count = 0
+	ctx := transactionidutils.TransactionAwareContext(context.Background(), "tid_reload")
+	assert.NoError(t, service.reloadDB(ctx))
+	assert.Equal(t, "tid_reload", listener.waitForLoads(t, 2).transactionID)
+
+	names := map[string]int{}
+	var load tracetest.SpanStub
+	for _, span := range exporter.GetSpans() {
+		names[span.Name]++
+		if span.Name == "loadDB" {
+			load = span
+		}
+	}
+	assert.Equal(t, map[string]int{"loadDB": 1, "GetTmeTermsFromIndex": 3, "processTerms": 2, "db.Batch": 2}, names)
+	assert.Equal(t, "tid_reload", spanAttribute(load, "ft.transaction_id"))
+	for _, span := range exporter.GetSpans() {
+		assert.Equal(t, load.SpanContext.TraceID(), span.SpanContext.TraceID(), span.Name)
+	}
+}
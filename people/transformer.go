@@ -3,6 +3,8 @@ package people
 import (
 	"encoding/base64"
 	"encoding/xml"
+	"fmt"
+	"strings"
 
 	"github.com/pborman/uuid"
 )
@@ -10,9 +12,63 @@ import (
 type PersonTransformer struct {
 }
 
-func transformPerson(tmeTerm term, taxonomyName string) person {
+//UUIDScheme derives the UUID of a person from its TME identifier, hashed with MD5 (version 3) or SHA1 (version 5) in the namespace
+type UUIDScheme struct {
+	Version   int
+	Namespace uuid.UUID
+}
+
+//LegacyUUIDScheme is the scheme people have always been given, MD5 without a namespace
+var LegacyUUIDScheme = UUIDScheme{Version: 3, Namespace: uuid.UUID{}}
+
+var uuidNamespaces = map[string]uuid.UUID{
+	"dns":  uuid.NameSpace_DNS,
+	"url":  uuid.NameSpace_URL,
+	"oid":  uuid.NameSpace_OID,
+	"x500": uuid.NameSpace_X500,
+}
+
+//ParseUUIDScheme reads a version of v3 or v5 and a namespace, either a UUID, one of dns, url, oid or x500, or empty for none
+func ParseUUIDScheme(version string, namespace string) (UUIDScheme, error) {
+	scheme := UUIDScheme{Namespace: uuid.UUID{}}
+	switch version {
+	case "v3":
+		scheme.Version = 3
+	case "v5":
+		scheme.Version = 5
+	default:
+		return scheme, fmt.Errorf("Unknown UUID version [%v], expected v3 or v5", version)
+	}
+	if namespace == "" {
+		return scheme, nil
+	}
+	if known, found := uuidNamespaces[strings.ToLower(namespace)]; found {
+		scheme.Namespace = known
+		return scheme, nil
+	}
+	if scheme.Namespace = uuid.Parse(namespace); scheme.Namespace == nil {
+		return scheme, fmt.Errorf("Invalid UUID namespace [%v]", namespace)
+	}
+	return scheme, nil
+}
+
+func (s UUIDScheme) personUUID(tmeIdentifier string) string {
+	if s.Version == 5 {
+		return uuid.NewSHA1(s.Namespace, []byte(tmeIdentifier)).String()
+	}
+	return uuid.NewMD5(s.Namespace, []byte(tmeIdentifier)).String()
+}
+
+//transformPerson gives the person its UUID in the scheme, recording the ones it had in the schemes migrated from
+func transformPerson(tmeTerm term, taxonomyName string, scheme UUIDScheme, migratedFrom ...UUIDScheme) person {
 	tmeIdentifier := buildTmeIdentifier(tmeTerm.RawID, taxonomyName)
-	personUUID := uuid.NewMD5(uuid.UUID{}, []byte(tmeIdentifier)).String()
+	personUUID := scheme.personUUID(tmeIdentifier)
+	uuids := []string{personUUID}
+	for _, previous := range migratedFrom {
+		if previousUUID := previous.personUUID(tmeIdentifier); previousUUID != personUUID {
+			uuids = append(uuids, previousUUID)
+		}
+	}
 	aliasList := buildAliasList(tmeTerm.Aliases)
 	return person{
 		UUID:      personUUID,
@@ -20,7 +76,7 @@ func transformPerson(tmeTerm term, taxonomyName string) person {
 		Name:      tmeTerm.CanonicalName,
 		AlternativeIdentifiers: alternativeIdentifiers{
 			TME:   []string{tmeIdentifier},
-			Uuids: []string{personUUID},
+			Uuids: uuids,
 		},
 		Type:    "Person",
 		Aliases: aliasList,
@@ -33,6 +89,23 @@ func buildTmeIdentifier(rawID string, tmeTermTaxonomyName string) string {
 	return id + "-" + taxonomyName
 }
 
+//parseTmeIdentifier reverses buildTmeIdentifier
+func parseTmeIdentifier(tmeIdentifier string) (string, string, error) {
+	parts := strings.SplitN(tmeIdentifier, "-", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("Invalid TME identifier [%v]", tmeIdentifier)
+	}
+	rawID, err := base64.StdEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", "", err
+	}
+	taxonomyName, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", "", err
+	}
+	return string(rawID), string(taxonomyName), nil
+}
+
 func buildAliasList(aList aliases) []string {
 	aliasList := make([]string, len(aList.Alias))
 	for k, v := range aList.Alias {
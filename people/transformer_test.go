@@ -1,9 +1,11 @@
 package people
 
 import (
+	"errors"
 	"testing"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/pborman/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -21,7 +23,7 @@ func TestTransformPerson(t *testing.T) {
 				{Name: "b"},
 			}},
 	}
-	tfp := transformPerson(testTerm, taxonomyName)
+	tfp := transformPerson(testTerm, taxonomyName, LegacyUUIDScheme)
 	log.Infof("got person %v", tfp)
 	assert.NotNil(t, tfp)
 	assert.Len(t, tfp.Aliases, 2)
@@ -31,3 +33,54 @@ func TestTransformPerson(t *testing.T) {
 	assert.Equal(t, "Bob", tfp.PrefLabel)
 	assert.Equal(t, "Bob", tfp.Name)
 }
+
+func TestParseUUIDScheme(t *testing.T) {
+	tests := []struct {
+		name      string
+		version   string
+		namespace string
+		scheme    UUIDScheme
+		err       error
+	}{
+		{"Legacy", "v3", "", LegacyUUIDScheme, nil},
+		{"Known namespace", "v5", "DNS", UUIDScheme{Version: 5, Namespace: uuid.NameSpace_DNS}, nil},
+		{"Custom namespace", "v5", "f0b5d1c2-7c1a-4a4e-9d47-2c8b8e0e2f11", UUIDScheme{Version: 5, Namespace: uuid.Parse("f0b5d1c2-7c1a-4a4e-9d47-2c8b8e0e2f11")}, nil},
+		{"Unknown version", "v4", "", UUIDScheme{Namespace: uuid.UUID{}}, errors.New("Unknown UUID version [v4], expected v3 or v5")},
+		{"Invalid namespace", "v3", "people", UUIDScheme{Version: 3}, errors.New("Invalid UUID namespace [people]")},
+	}
+	for _, test := range tests {
+		scheme, err := ParseUUIDScheme(test.version, test.namespace)
+		assert.Equal(t, test.err, err, test.name)
+		assert.Equal(t, test.scheme, scheme, test.name)
+	}
+}
+
+func TestTransformPersonWithUUIDScheme(t *testing.T) {
+	testTerm := term{CanonicalName: "Bob", RawID: "bob"}
+	v5 := UUIDScheme{Version: 5, Namespace: uuid.NameSpace_URL}
+
+	p := transformPerson(testTerm, taxonomyName, v5)
+	version, _ := uuid.Parse(p.UUID).Version()
+	assert.Equal(t, uuid.Version(5), version)
+	assert.NotEqual(t, "0e86d39b-8320-3a98-a87a-ff35d2cb04b9", p.UUID)
+	assert.Equal(t, []string{p.UUID}, p.AlternativeIdentifiers.Uuids)
+
+	migrated := transformPerson(testTerm, taxonomyName, v5, LegacyUUIDScheme)
+	assert.Equal(t, p.UUID, migrated.UUID)
+	assert.Equal(t, []string{p.UUID, "0e86d39b-8320-3a98-a87a-ff35d2cb04b9"}, migrated.AlternativeIdentifiers.Uuids)
+
+	unchanged := transformPerson(testTerm, taxonomyName, LegacyUUIDScheme, LegacyUUIDScheme)
+	assert.Equal(t, []string{"0e86d39b-8320-3a98-a87a-ff35d2cb04b9"}, unchanged.AlternativeIdentifiers.Uuids, "A UUID is only recorded once")
+}
+
+func TestParseTmeIdentifier(t *testing.T) {
+	rawID, taxonomy, err := parseTmeIdentifier(buildTmeIdentifier("bob", taxonomyName))
+	assert.NoError(t, err)
+	assert.Equal(t, "bob", rawID)
+	assert.Equal(t, taxonomyName, taxonomy)
+
+	_, _, err = parseTmeIdentifier("no-separator-but-not-base64!")
+	assert.Error(t, err)
+	_, _, err = parseTmeIdentifier("nodash")
+	assert.Error(t, err)
+}
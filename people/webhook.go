@@ -0,0 +1,117 @@
+package people
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+const (
+	reloadCompletedEvent = "reload.completed"
+	signatureHeader      = "X-Signature"
+)
+
+//reloadNotification is the body of the webhook sent when a load finishes
+type reloadNotification struct {
+	Event         string    `json:"event"`
+	TransactionID string    `json:"transactionId"`
+	Status        string    `json:"status"`
+	Error         string    `json:"error,omitempty"`
+	Count         int       `json:"count"`
+	Added         int       `json:"added"`
+	Changed       int       `json:"changed"`
+	Removed       int       `json:"removed"`
+	Migrated      int       `json:"migrated"`
+	DurationMs    int64     `json:"durationMs"`
+	Generation    int       `json:"generation"`
+	FinishedAt    time.Time `json:"finishedAt"`
+}
+
+//WithWebhooks posts a notification signed with the secret to each url when a load finishes, the client does the retries
+func WithWebhooks(client httpClient, urls []string, secret string) ServiceOption {
+	return func(s *peopleServiceImpl) {
+		s.loadListeners = append(s.loadListeners, &webhookNotifier{client: client, urls: urls, secret: secret})
+	}
+}
+
+type webhookNotifier struct {
+	client httpClient
+	urls   []string
+	secret string
+}
+
+func (n *webhookNotifier) loadFinished(load loadResult) {
+	notification := reloadNotification{
+		Event:         reloadCompletedEvent,
+		TransactionID: load.transactionID,
+		Status:        "success",
+		Count:         load.count,
+		Added:         load.countOf(personAdded),
+		Changed:       load.countOf(personChanged),
+		Removed:       load.countOf(personRemoved),
+		Migrated:      load.countOf(personMigrated),
+		DurationMs:    int64(load.duration / time.Millisecond),
+		Generation:    load.generation,
+		FinishedAt:    load.started.Add(load.duration).UTC(),
+	}
+	if load.err != nil {
+		notification.Status = "failed"
+		notification.Error = load.err.Error()
+	}
+	body, err := json.Marshal(notification)
+	if err != nil {
+		log.Errorf("ERROR building reload notification: %v", err.Error())
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, url := range n.urls {
+		wg.Add(1)
+		go func(url string) {
+			defer wg.Done()
+			if err := n.post(url, body, load.transactionID); err != nil {
+				event(load.logger(), "webhook_failed").WithField("url", url).WithError(err).Errorf("ERROR notifying %v of load %v: %v", url, load.transactionID, err.Error())
+			}
+		}(url)
+	}
+	wg.Wait()
+}
+
+func (n *webhookNotifier) post(url string, body []byte, transactionID string) error {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-Id", transactionID)
+	if n.secret != "" {
+		req.Header.Set(signatureHeader, signBody(n.secret, body))
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("Webhook returned status %v", resp.StatusCode)
+	}
+	return nil
+}
+
+//signBody gives the HMAC-SHA256 of the body that receivers recompute with the shared secret
+func signBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,62 @@
+package people
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sethgrid/pester"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	var lock sync.Mutex
+	var bodies [][]byte
+	attempts := 0
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, err := ioutil.ReadAll(req.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, signBody("secret", body), req.Header.Get("X-Signature"))
+		assert.Equal(t, "tid_test", req.Header.Get("X-Request-Id"))
+		bodies = append(bodies, body)
+	}))
+	defer hook.Close()
+
+	client := pester.New()
+	client.Backoff = func(int) time.Duration { return time.Millisecond }
+	notifier := &webhookNotifier{client: client, urls: []string{hook.URL}, secret: "secret"}
+	notifier.loadFinished(loadResult{
+		transactionID: "tid_test",
+		started:       time.Date(2017, 3, 1, 10, 0, 0, 0, time.UTC),
+		duration:      1500 * time.Millisecond,
+		count:         2,
+		generation:    3,
+		changes:       []personChange{{uuid: testUUID, change: personAdded}, {uuid: testUUID2, change: personRemoved}},
+	})
+	notifier.loadFinished(loadResult{transactionID: "tid_test", err: errors.New("TME is down")})
+
+	assert.Equal(t, 3, attempts, "The first attempt should be retried")
+	assert.Len(t, bodies, 2)
+	assert.JSONEq(t, `{"event":"reload.completed","transactionId":"tid_test","status":"success","count":2,"added":1,"changed":0,"removed":1,"migrated":0,"durationMs":1500,"generation":3,"finishedAt":"2017-03-01T10:00:01.5Z"}`, string(bodies[0]))
+
+	var failed reloadNotification
+	assert.NoError(t, json.Unmarshal(bodies[1], &failed))
+	assert.Equal(t, "failed", failed.Status)
+	assert.Equal(t, "TME is down", failed.Error)
+}
+
+func TestSignBody(t *testing.T) {
+	assert.Equal(t, "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8", signBody("key", []byte("The quick brown fox jumps over the lazy dog")))
+}
@@ -0,0 +1,175 @@
+package people
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//conceptWrite is a person to PUT to the concept RW service, or to DELETE when removed
+type conceptWrite struct {
+	uuid             string
+	marshalledPerson []byte
+	remove           bool
+}
+
+//writeSummary reports how a load was written to the concept RW service
+type writeSummary struct {
+	written  int
+	deleted  int
+	skipped  int
+	failed   int
+	duration time.Duration
+}
+
+//WithConceptWriter PUTs the people of each load, or only the ones it added or changed, to a concept RW service and DELETEs the removed ones
+func WithConceptWriter(client httpClient, rwURL string, concurrency int, onlyChanged bool) ServiceOption {
+	return func(s *peopleServiceImpl) {
+		if concurrency < 1 {
+			concurrency = 1
+		}
+		s.loadListeners = append(s.loadListeners, &conceptWriter{
+			client:      client,
+			url:         strings.TrimSuffix(rwURL, "/"),
+			concurrency: concurrency,
+			onlyChanged: onlyChanged,
+			service:     s,
+		})
+	}
+}
+
+type conceptWriter struct {
+	client      httpClient
+	url         string
+	concurrency int
+	onlyChanged bool
+	service     *peopleServiceImpl
+}
+
+func (w *conceptWriter) loadFinished(load loadResult) {
+	if load.err != nil {
+		log.Warnf("Not writing the people of the failed load %v to %v: %v", load.transactionID, w.url, load.err.Error())
+		return
+	}
+	writes, err := w.writesFor(load)
+	if err != nil {
+		log.Errorf("ERROR listing the people of load %v to write to %v: %v", load.transactionID, w.url, err.Error())
+		return
+	}
+	summary := w.write(writes, load.transactionID)
+	event(load.logger(), "concepts_written").WithFields(log.Fields{
+		"url":         w.url,
+		"duration_ms": durationMillis(summary.duration),
+		"written":     summary.written,
+		"deleted":     summary.deleted,
+		"skipped":     summary.skipped,
+		"failed":      summary.failed,
+	}).Infof("Wrote load %v to %v in %v: %v written, %v deleted, %v skipped, %v failed.", load.transactionID, w.url, summary.duration, summary.written, summary.deleted, summary.skipped, summary.failed)
+}
+
+func (w *conceptWriter) writesFor(load loadResult) ([]conceptWrite, error) {
+	var writes []conceptWrite
+	for _, change := range load.changes {
+		if change.change == personMigrated {
+			//the person is written by the UUID it migrated to, the concept by its previous UUID is kept
+			continue
+		}
+		if change.change == personRemoved {
+			writes = append(writes, conceptWrite{uuid: change.uuid, remove: true})
+		} else if w.onlyChanged {
+			writes = append(writes, conceptWrite{uuid: change.uuid, marshalledPerson: change.marshalledPerson})
+		}
+	}
+	if w.onlyChanged {
+		return writes, nil
+	}
+	uuids, err := w.service.getStoredUUIDs()
+	if err != nil {
+		return nil, err
+	}
+	for _, uuid := range uuids {
+		writes = append(writes, conceptWrite{uuid: uuid})
+	}
+	return writes, nil
+}
+
+//write runs the writes with at most concurrency requests in flight, reading the people not in the load changes from the cache
+func (w *conceptWriter) write(writes []conceptWrite, transactionID string) writeSummary {
+	var summary writeSummary
+	var lock sync.Mutex
+	start := time.Now()
+	queue := make(chan conceptWrite)
+	var wg sync.WaitGroup
+	for i := 0; i < w.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for write := range queue {
+				written, err := w.send(write, transactionID)
+				lock.Lock()
+				switch {
+				case err != nil:
+					log.Errorf("ERROR writing [%v] to %v: %v", write.uuid, w.url, err.Error())
+					summary.failed++
+				case !written:
+					summary.skipped++
+				case write.remove:
+					summary.deleted++
+				default:
+					summary.written++
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+	for _, write := range writes {
+		queue <- write
+	}
+	close(queue)
+	wg.Wait()
+	summary.duration = time.Since(start)
+	return summary
+}
+
+func (w *conceptWriter) send(write conceptWrite, transactionID string) (bool, error) {
+	method, body := "PUT", write.marshalledPerson
+	if write.remove {
+		method = "DELETE"
+	} else if body == nil {
+		p, found, err := w.service.getPersonByUUID(write.uuid)
+		if err != nil || !found {
+			return false, err
+		}
+		if body, err = json.Marshal(p); err != nil {
+			return false, err
+		}
+	}
+
+	req, err := http.NewRequest(method, w.url+"/"+write.uuid, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-Id", transactionID)
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	switch {
+	case write.remove && resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return false, fmt.Errorf("%v returned status %v", method, resp.StatusCode)
+	}
+	return true, nil
+}
@@ -0,0 +1,125 @@
+package people
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	bobUUID  = "be2e7e2b-0fa2-3969-a69b-74c46e754032"
+	fredUUID = "28d66fcc-bb56-363d-80c1-f2d957ef58cf"
+)
+
+func TestConceptWriter(t *testing.T) {
+	tests := []struct {
+		name        string
+		onlyChanged bool
+		first       []string
+		second      []string
+	}{
+		{"All people", false,
+			[]string{"PUT /people/" + fredUUID, "PUT /people/" + bobUUID},
+			[]string{"DELETE /people/" + bobUUID, "PUT /people/" + fredUUID}},
+		{"Only changed people", true,
+			[]string{"PUT /people/" + fredUUID, "PUT /people/" + bobUUID},
+			[]string{"DELETE /people/" + bobUUID, "PUT /people/" + fredUUID}},
+	}
+	for _, test := range tests {
+		rw := &recordingRW{}
+		server := httptest.NewServer(rw)
+		tmpfile := getTempFile(t)
+		repo := dummyRepo{terms: []term{{CanonicalName: "Bob", RawID: "bob"}, {CanonicalName: "Fred", RawID: "fred"}}}
+		listener := &recordingListener{}
+		service := NewPeopleService(&repo, "/base/url", "taxonomy_string", 1, tmpfile.Name(),
+			WithConceptWriter(http.DefaultClient, server.URL+"/people/", 2, test.onlyChanged),
+			thenLoadListener(listener))
+
+		listener.waitForLoads(t, 1)
+		assert.Equal(t, test.first, rw.sortedRequests(), test.name)
+		assert.Equal(t, "Fred", rw.prefLabels[fredUUID], test.name)
+
+		repo.terms = []term{{CanonicalName: "Frederick", RawID: "fred"}}
+		repo.count = 0
+		assert.NoError(t, service.reloadDB(context.Background()))
+		listener.waitForLoads(t, 2)
+		assert.Equal(t, test.second, rw.sortedRequests(), test.name)
+		assert.Equal(t, "Frederick", rw.prefLabels[fredUUID], test.name)
+
+		repo.count = 0
+		assert.NoError(t, service.reloadDB(context.Background()))
+		listener.waitForLoads(t, 3)
+		if test.onlyChanged {
+			assert.Empty(t, rw.sortedRequests(), test.name)
+		} else {
+			assert.Equal(t, []string{"PUT /people/" + fredUUID}, rw.sortedRequests(), test.name)
+		}
+
+		service.Shutdown()
+		server.Close()
+		os.Remove(tmpfile.Name())
+	}
+}
+
+func TestConceptWriterSummary(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/people/failing":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/people/missing":
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	writer := &conceptWriter{client: http.DefaultClient, url: server.URL + "/people", concurrency: 3}
+	summary := writer.write([]conceptWrite{
+		{uuid: "written", marshalledPerson: []byte("{}")},
+		{uuid: "failing", marshalledPerson: []byte("{}")},
+		{uuid: "deleted", remove: true},
+		{uuid: "missing", remove: true},
+	}, "tid_test")
+	assert.Equal(t, 1, summary.written)
+	assert.Equal(t, 1, summary.deleted)
+	assert.Equal(t, 1, summary.skipped)
+	assert.Equal(t, 1, summary.failed)
+}
+
+//recordingRW collects the requests made since the last call to sortedRequests
+type recordingRW struct {
+	sync.Mutex
+	requests   []string
+	prefLabels map[string]string
+}
+
+func (rw *recordingRW) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	rw.Lock()
+	defer rw.Unlock()
+	rw.requests = append(rw.requests, req.Method+" "+req.URL.Path)
+	if req.Method == "PUT" {
+		body, _ := ioutil.ReadAll(req.Body)
+		var p person
+		json.Unmarshal(body, &p)
+		if rw.prefLabels == nil {
+			rw.prefLabels = map[string]string{}
+		}
+		rw.prefLabels[p.UUID] = p.PrefLabel
+	}
+}
+
+func (rw *recordingRW) sortedRequests() []string {
+	rw.Lock()
+	defer rw.Unlock()
+	requests := rw.requests
+	rw.requests = nil
+	sort.Strings(requests)
+	return requests
+}